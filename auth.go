@@ -0,0 +1,157 @@
+package discogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+	"github.com/dkaman/discogs-golang/oauth"
+)
+
+// authenticator sets whatever credentials a client was built with on the
+// requests Do sends, on every attempt. userAuth reports whether they act on
+// behalf of a discogs user, which endpoints touching someone's collection
+// or identity need, as opposed to only identifying the application.
+// identity tells credentials apart, it is only ever used hashed.
+type authenticator interface {
+	authenticate(req *http.Request) error
+	userAuth() bool
+	identity() string
+}
+
+type tokenAuth string
+
+func (t tokenAuth) authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Discogs token="+string(t))
+	return nil
+}
+
+func (tokenAuth) userAuth() bool { return true }
+
+func (t tokenAuth) identity() string { return "token:" + string(t) }
+
+type consumerAuth struct {
+	key    string
+	secret string
+}
+
+func (a consumerAuth) authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", fmt.Sprintf("Discogs key=%s, secret=%s", a.key, a.secret))
+	return nil
+}
+
+func (consumerAuth) userAuth() bool { return false }
+
+func (a consumerAuth) identity() string { return "consumer:" + a.key }
+
+type oauthAuth struct {
+	config *oauth.Config
+	token  *oauth.Token
+}
+
+func (a oauthAuth) authenticate(req *http.Request) error {
+	return a.config.Sign(req, a.token)
+}
+
+func (oauthAuth) userAuth() bool { return true }
+
+func (a oauthAuth) identity() string { return "oauth:" + a.config.ConsumerKey + ":" + a.token.Token }
+
+// WithToken authenticates with a personal access token. the token isn't
+// checked until the first request, call Validate to check it up front.
+func WithToken(token string) options.Option[Client] {
+	return func(c *Client) error {
+		c.auth = tokenAuth(token)
+		c.useAuthenticatedRateLimit()
+
+		return nil
+	}
+}
+
+// WithOAuth signs every request Do sends, retries included, on behalf of
+// the user that granted token, see the oauth package for obtaining one.
+func WithOAuth(config *oauth.Config, token *oauth.Token) options.Option[Client] {
+	return func(c *Client) error {
+		if config == nil || token == nil {
+			return errors.New("oauth config and token are required")
+		}
+
+		c.auth = oauthAuth{config: config, token: token}
+		c.useAuthenticatedRateLimit()
+
+		return nil
+	}
+}
+
+// WithConsumerCredentials authenticates as the application only. that gets
+// the authenticated rate limit, database search and image urls, but every
+// endpoint acting on a user's behalf will fail with ErrUserAuthRequired.
+func WithConsumerCredentials(key string, secret string) options.Option[Client] {
+	return func(c *Client) error {
+		if key == "" || secret == "" {
+			return errors.New("consumer key and secret are required")
+		}
+
+		c.auth = consumerAuth{key: key, secret: secret}
+		c.useAuthenticatedRateLimit()
+
+		return nil
+	}
+}
+
+func (c *Client) useAuthenticatedRateLimit() {
+	// start a little under the 60 request per minute budget, the
+	// limiter tunes itself once the first response comes back
+	if !c.customRateLimiter {
+		c.rateLimiter = newAdaptiveLimiter(55)
+	}
+}
+
+// requireUserAuth checks the credentials a call made with ctx uses.
+func (c *Client) requireUserAuth(ctx context.Context) error {
+	auth := c.authFor(ctx)
+	if auth == nil || !auth.userAuth() {
+		return ErrUserAuthRequired
+	}
+	return nil
+}
+
+// Validate checks the client's credentials against discogs and caches the
+// identity they belong to, replacing whatever was cached before. with
+// credentials in ctx those are checked instead and nothing is cached.
+func (c *Client) Validate(ctx context.Context) (id *Identity, err error) {
+	ctx, end := c.startOperation(ctx, "Client.Validate")
+	defer end(&err)
+
+	id, err = c.Identity.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error validating credentials: %w", err)
+	}
+
+	return id, nil
+}
+
+func (c *Client) cachedIdentity() *Identity {
+	c.identityMu.Lock()
+	defer c.identityMu.Unlock()
+	return c.identity
+}
+
+func (c *Client) setIdentity(id *Identity) {
+	c.identityMu.Lock()
+	defer c.identityMu.Unlock()
+	c.identity = id
+}
+
+// requestIdentity tells apart the credentials req was authenticated with.
+func (c *Client) requestIdentity(req *http.Request) string {
+	if creds := requestConfigFrom(req.Context()).creds; creds != nil {
+		return creds.auth.identity()
+	}
+	if c.auth == nil {
+		return ""
+	}
+	return c.auth.identity()
+}
@@ -0,0 +1,109 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkaman/discogs-golang/oauth"
+)
+
+func TestOAuthSignsEveryAttempt(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		headers []string
+		queries []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Get("Authorization"))
+		queries = append(queries, r.URL.RawQuery)
+		n := len(headers)
+		mu.Unlock()
+
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	config := &oauth.Config{
+		ConsumerKey:     "key",
+		ConsumerSecret:  "secret",
+		SignatureMethod: oauth.HMACSHA1,
+	}
+
+	// a middleware changing the url after NewRequest, the signature has to
+	// cover what it did
+	var sawAuth bool
+	addParam := func(next Handler) Handler {
+		return func(ctx context.Context, req *http.Request) (*Response, error) {
+			if req.Header.Get("Authorization") != "" {
+				sawAuth = true
+			}
+			q := req.URL.Query()
+			q.Set("added", "1")
+			req.URL.RawQuery = q.Encode()
+			return next(ctx, req)
+		}
+	}
+
+	c, err := New(
+		WithBaseURL(srv.URL),
+		WithOAuth(config, &oauth.Token{Token: "token", Secret: "token-secret"}),
+		WithRateLimiter(NewRateLimiter(60000)),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+		WithMiddleware(addParam),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := c.NewRequest(context.Background(), http.MethodGet, "oauth/identity", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Do(context.Background(), req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sawAuth {
+		t.Error("request was signed before the middleware ran")
+	}
+
+	if len(headers) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(headers))
+	}
+
+	if headers[0] == headers[1] {
+		t.Error("retry resent the same oauth Authorization header")
+	}
+
+	for i, h := range headers {
+		if !strings.HasPrefix(h, "OAuth ") {
+			t.Errorf("attempt %d: Authorization = %q, want an oauth header", i+1, h)
+		}
+		if queries[i] != "added=1" {
+			t.Errorf("attempt %d: query = %q, want added=1", i+1, queries[i])
+		}
+	}
+
+	if nonce(headers[0]) == nonce(headers[1]) {
+		t.Errorf("both attempts used nonce %s", nonce(headers[0]))
+	}
+}
+
+func nonce(header string) string {
+	_, rest, _ := strings.Cut(header, `oauth_nonce="`)
+	n, _, _ := strings.Cut(rest, `"`)
+	return n
+}
@@ -0,0 +1,356 @@
+package discogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitOpenError is returned for calls the circuit breaker turned away
+// without sending them. it matches ErrCircuitOpen with errors.Is.
+type CircuitOpenError struct {
+	// RetryAt is when the breaker lets a probe through, zero while one is
+	// already in flight.
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.RetryAt.IsZero() {
+		return "circuit breaker open, probe in flight"
+	}
+	return fmt.Sprintf("circuit breaker open until %s", e.RetryAt.Format(time.RFC3339))
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+// BreakerConfig sets when the circuit breaker opens. a failure is an http
+// call that didn't get a response or got a 5xx back, 429s and calls the
+// caller cancelled don't count either way. at least one of
+// ConsecutiveFailures and ErrorRate has to be set.
+type BreakerConfig struct {
+	// ConsecutiveFailures opens the breaker after that many failures in a
+	// row.
+	ConsecutiveFailures int
+
+	// ErrorRate opens the breaker once that share of the calls in the
+	// last Window failed, 0.5 for half of them, as long as there were at
+	// least MinRequests. Window defaults to a minute and MinRequests to 10.
+	ErrorRate   float64
+	Window      time.Duration
+	MinRequests int
+
+	// OpenTimeout is how long the breaker stays open before letting a
+	// probe through, 30 seconds by default.
+	OpenTimeout time.Duration
+
+	// HalfOpenSuccesses is how many probes in a row have to succeed to
+	// close the breaker again, 1 by default. probes go one at a time, any
+	// of them failing opens it again.
+	HalfOpenSuccesses int
+
+	// OnStateChange is called on every transition, on the goroutine of
+	// the call that caused it.
+	OnStateChange func(from, to BreakerState)
+}
+
+// WithCircuitBreaker stops the client from sending anything for a while
+// once discogs looks to be down, failing calls with a *CircuitOpenError
+// instead. the breaker is checked before each attempt, so retries stop
+// as soon as it opens. cached responses are still served.
+func WithCircuitBreaker(cfg BreakerConfig) options.Option[Client] {
+	return func(c *Client) error {
+		if cfg.ConsecutiveFailures <= 0 && cfg.ErrorRate <= 0 {
+			return errors.New("circuit breaker needs ConsecutiveFailures or ErrorRate")
+		}
+
+		if cfg.ErrorRate > 1 {
+			return fmt.Errorf("circuit breaker error rate must be at most 1, got %v", cfg.ErrorRate)
+		}
+
+		if cfg.Window <= 0 {
+			cfg.Window = time.Minute
+		}
+
+		if cfg.MinRequests <= 0 {
+			cfg.MinRequests = 10
+		}
+
+		if cfg.OpenTimeout <= 0 {
+			cfg.OpenTimeout = 30 * time.Second
+		}
+
+		if cfg.HalfOpenSuccesses <= 0 {
+			cfg.HalfOpenSuccesses = 1
+		}
+
+		c.breaker = &breaker{config: cfg}
+		return nil
+	}
+}
+
+// BreakerState returns the state of the circuit breaker, BreakerClosed if
+// the client doesn't have one.
+func (c *Client) BreakerState() BreakerState {
+	if c.breaker == nil {
+		return BreakerClosed
+	}
+
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+
+	// an open breaker past its timeout only turns half open when the next
+	// call comes in, report it as it would be then
+	if c.breaker.state == BreakerOpen && !time.Now().Before(c.breaker.openUntil) {
+		return BreakerHalfOpen
+	}
+	return c.breaker.state
+}
+
+type breaker struct {
+	config BreakerConfig
+
+	mu          sync.Mutex
+	state       BreakerState
+	consecutive int
+	outcomes    []outcome
+	openUntil   time.Time
+	probing     bool
+	successes   int
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+type transition struct {
+	from, to BreakerState
+}
+
+// allow reports whether an attempt may go out. in half open only one probe
+// is let through at a time, the caller has to report its result.
+func (b *breaker) allow(now time.Time) (probe bool, t *transition, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		if now.Before(b.openUntil) {
+			return false, nil, &CircuitOpenError{RetryAt: b.openUntil}
+		}
+		t = b.setState(BreakerHalfOpen)
+	}
+
+	if b.state == BreakerHalfOpen {
+		if b.probing {
+			return false, t, &CircuitOpenError{}
+		}
+		b.probing = true
+		return true, t, nil
+	}
+
+	return false, t, nil
+}
+
+// record takes the result of an attempt allow let through. ignored
+// attempts, like ones the caller cancelled, only free up the probe slot.
+func (b *breaker) record(now time.Time, probe bool, failed bool, ignored bool) *transition {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	if ignored {
+		return nil
+	}
+
+	if b.state == BreakerHalfOpen {
+		// a call allowed in before the breaker opened can still land here,
+		// only the probe decides
+		if !probe {
+			return nil
+		}
+
+		if failed {
+			return b.open(now)
+		}
+
+		b.successes++
+		if b.successes >= b.config.HalfOpenSuccesses {
+			return b.setState(BreakerClosed)
+		}
+		return nil
+	}
+
+	if b.state == BreakerOpen {
+		return nil
+	}
+
+	if failed {
+		b.consecutive++
+	} else {
+		b.consecutive = 0
+	}
+
+	if b.config.ErrorRate > 0 {
+		b.outcomes = append(b.outcomes, outcome{at: now, failed: failed})
+		b.trim(now)
+	}
+
+	if b.config.ConsecutiveFailures > 0 && b.consecutive >= b.config.ConsecutiveFailures {
+		return b.open(now)
+	}
+
+	if failed && b.config.ErrorRate > 0 && b.errorRate(now) >= b.config.ErrorRate {
+		return b.open(now)
+	}
+
+	return nil
+}
+
+// trim drops outcomes older than the window, on every call recorded so a
+// client that never fails doesn't keep them all.
+func (b *breaker) trim(now time.Time) {
+	cutoff := now.Add(-b.config.Window)
+
+	i := 0
+	for i < len(b.outcomes) && b.outcomes[i].at.Before(cutoff) {
+		i++
+	}
+	b.outcomes = b.outcomes[i:]
+}
+
+// errorRate returns the share of failures in the window, 0 while there
+// are fewer than MinRequests.
+func (b *breaker) errorRate(now time.Time) float64 {
+	b.trim(now)
+
+	if len(b.outcomes) < b.config.MinRequests {
+		return 0
+	}
+
+	failed := 0
+	for _, o := range b.outcomes {
+		if o.failed {
+			failed++
+		}
+	}
+
+	return float64(failed) / float64(len(b.outcomes))
+}
+
+func (b *breaker) open(now time.Time) *transition {
+	b.openUntil = now.Add(b.config.OpenTimeout)
+	return b.setState(BreakerOpen)
+}
+
+func (b *breaker) setState(state BreakerState) *transition {
+	t := &transition{from: b.state, to: state}
+
+	b.state = state
+	b.consecutive = 0
+	b.outcomes = nil
+	b.successes = 0
+
+	return t
+}
+
+// breakerFailure decides how an attempt counts against the breaker.
+func breakerFailure(ctx context.Context, resp *http.Response, err error) (failed bool, ignored bool) {
+	if err != nil {
+		// the caller giving up says nothing about discogs
+		if ctx.Err() != nil {
+			return false, true
+		}
+		return true, false
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return false, true
+	}
+
+	return resp.StatusCode >= 500, false
+}
+
+// breakerAllow asks the client's breaker, if it has one, whether an
+// attempt may go out. probe has to be handed back to breakerRecord or
+// breakerSkip.
+func (c *Client) breakerAllow(ctx context.Context) (probe bool, err error) {
+	if c.breaker == nil {
+		return false, nil
+	}
+
+	probe, t, err := c.breaker.allow(time.Now())
+	c.breakerChanged(ctx, t)
+
+	return probe, err
+}
+
+func (c *Client) breakerRecord(ctx context.Context, probe bool, resp *http.Response, err error) {
+	if c.breaker == nil {
+		return
+	}
+
+	failed, ignored := breakerFailure(ctx, resp, err)
+	c.breakerChanged(ctx, c.breaker.record(time.Now(), probe, failed, ignored))
+}
+
+// breakerSkip is for attempts that were allowed but never sent.
+func (c *Client) breakerSkip(probe bool) {
+	if c.breaker == nil {
+		return
+	}
+
+	c.breaker.record(time.Now(), probe, false, true)
+}
+
+func (c *Client) breakerChanged(ctx context.Context, t *transition) {
+	if t == nil || t.from == t.to {
+		return
+	}
+
+	level := slog.LevelInfo
+	if t.to == BreakerOpen {
+		level = slog.LevelWarn
+	}
+
+	c.logger.LogAttrs(ctx, level, "discogs circuit breaker changed state",
+		slog.String("from", t.from.String()),
+		slog.String("to", t.to.String()),
+	)
+
+	if c.breaker.config.OnStateChange != nil {
+		c.breaker.config.OnStateChange(t.from, t.to)
+	}
+}
@@ -0,0 +1,219 @@
+package discogs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(t *testing.T, cfg BreakerConfig) (*Client, *[]transition) {
+	t.Helper()
+
+	var changes []transition
+	cfg.OnStateChange = func(from, to BreakerState) {
+		changes = append(changes, transition{from: from, to: to})
+	}
+
+	c, err := New(WithCircuitBreaker(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, &changes
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	b := &breaker{config: BreakerConfig{
+		ConsecutiveFailures: 3,
+		OpenTimeout:         30 * time.Second,
+		HalfOpenSuccesses:   2,
+	}}
+
+	now := time.Now()
+
+	// a success in between resets the count
+	for _, failed := range []bool{true, true, false, true, true} {
+		if tr := b.record(now, false, failed, false); tr != nil {
+			t.Fatalf("breaker changed to %s before three failures in a row", tr.to)
+		}
+	}
+
+	tr := b.record(now, false, true, false)
+	if tr == nil || tr.from != BreakerClosed || tr.to != BreakerOpen {
+		t.Fatalf("third failure in a row gave %+v, want closed to open", tr)
+	}
+
+	_, _, err := b.allow(now.Add(29 * time.Second))
+	var open *CircuitOpenError
+	if !errors.As(err, &open) || !open.RetryAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("open breaker allowed a call, err %v", err)
+	}
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Error("CircuitOpenError doesn't match ErrCircuitOpen")
+	}
+
+	// past the timeout one probe goes out, anything else waits for it
+	now = now.Add(30 * time.Second)
+	probe, tr, err := b.allow(now)
+	if err != nil || !probe || tr == nil || tr.to != BreakerHalfOpen {
+		t.Fatalf("after the timeout got probe %v, %+v, %v, want a half open probe", probe, tr, err)
+	}
+
+	if _, _, err := b.allow(now); !errors.As(err, &open) || !open.RetryAt.IsZero() {
+		t.Fatalf("second call during a probe got %v", err)
+	}
+
+	// a failed probe opens it again
+	if tr := b.record(now, true, true, false); tr == nil || tr.to != BreakerOpen {
+		t.Fatalf("failed probe gave %+v, want open", tr)
+	}
+
+	now = now.Add(30 * time.Second)
+
+	// it takes two good probes in a row to close
+	for i := range 2 {
+		probe, _, err := b.allow(now)
+		if err != nil || !probe {
+			t.Fatalf("probe %d not allowed: %v", i, err)
+		}
+
+		// a call let in before the breaker opened doesn't count
+		if tr := b.record(now, false, false, false); tr != nil {
+			t.Fatalf("non probe call changed the breaker to %s", tr.to)
+		}
+
+		tr := b.record(now, true, false, false)
+		if i == 0 && tr != nil {
+			t.Fatalf("first good probe changed the breaker to %s", tr.to)
+		}
+		if i == 1 && (tr == nil || tr.from != BreakerHalfOpen || tr.to != BreakerClosed) {
+			t.Fatalf("second good probe gave %+v, want half open to closed", tr)
+		}
+	}
+
+	if probe, tr, err := b.allow(now); probe || tr != nil || err != nil {
+		t.Errorf("closed breaker gave probe %v, %+v, %v", probe, tr, err)
+	}
+}
+
+func TestBreakerErrorRate(t *testing.T) {
+	b := &breaker{config: BreakerConfig{
+		ErrorRate:   0.5,
+		Window:      time.Minute,
+		MinRequests: 4,
+		OpenTimeout: time.Second,
+	}}
+
+	now := time.Now()
+
+	// failures that have left the window don't count
+	b.record(now.Add(-2*time.Minute), false, true, false)
+	b.record(now.Add(-2*time.Minute), false, true, false)
+
+	for _, failed := range []bool{false, true, false} {
+		if tr := b.record(now, false, failed, false); tr != nil {
+			t.Fatalf("breaker opened below MinRequests")
+		}
+	}
+
+	if tr := b.record(now, false, true, false); tr == nil || tr.to != BreakerOpen {
+		t.Fatalf("half of four calls failing gave %+v, want open", tr)
+	}
+}
+
+func TestBreakerIgnoredOutcomes(t *testing.T) {
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		status          int
+		err             error
+		failed, ignored bool
+	}{
+		{name: "success", ctx: ctx, status: 200},
+		{name: "client error", ctx: ctx, status: 404},
+		{name: "server error", ctx: ctx, status: 503, failed: true},
+		{name: "rate limited", ctx: ctx, status: 429, ignored: true},
+		{name: "network error", ctx: ctx, err: errors.New("connection refused"), failed: true},
+		{name: "cancelled", ctx: cancelled, err: context.Canceled, ignored: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+
+			failed, ignored := breakerFailure(tt.ctx, resp, tt.err)
+			if failed != tt.failed || ignored != tt.ignored {
+				t.Errorf("got failed %v ignored %v, want %v %v", failed, ignored, tt.failed, tt.ignored)
+			}
+		})
+	}
+}
+
+func TestBreakerSkipReleasesProbe(t *testing.T) {
+	c, changes := newTestBreaker(t, BreakerConfig{ConsecutiveFailures: 1, OpenTimeout: time.Millisecond})
+	ctx := context.Background()
+
+	c.breakerRecord(ctx, false, nil, errors.New("connection refused"))
+	if state := c.breaker.state; state != BreakerOpen {
+		t.Fatalf("breaker is %s after a failure, want open", state)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	probe, err := c.breakerAllow(ctx)
+	if err != nil || !probe {
+		t.Fatalf("no probe after the timeout: %v", err)
+	}
+
+	// the probe never went out, say the request couldn't be signed
+	c.breakerSkip(probe)
+
+	if c.BreakerState() != BreakerHalfOpen {
+		t.Errorf("skipping the probe moved the breaker to %s", c.BreakerState())
+	}
+
+	probe, err = c.breakerAllow(ctx)
+	if err != nil || !probe {
+		t.Fatalf("probe slot not released by breakerSkip: %v", err)
+	}
+
+	c.breakerRecord(ctx, probe, &http.Response{StatusCode: 200}, nil)
+
+	want := []transition{
+		{from: BreakerClosed, to: BreakerOpen},
+		{from: BreakerOpen, to: BreakerHalfOpen},
+		{from: BreakerHalfOpen, to: BreakerClosed},
+	}
+	if !slices.Equal(*changes, want) {
+		t.Errorf("transitions %v, want %v", *changes, want)
+	}
+}
+
+func TestBreakerTrimsOutcomesOnSuccess(t *testing.T) {
+	b := &breaker{config: BreakerConfig{
+		ErrorRate:   0.5,
+		Window:      time.Minute,
+		MinRequests: 10,
+		OpenTimeout: time.Second,
+	}}
+
+	// a call a second for an hour, none failing
+	start := time.Now()
+	for i := range 3600 {
+		b.record(start.Add(time.Duration(i)*time.Second), false, false, false)
+	}
+
+	if n := len(b.outcomes); n > 61 {
+		t.Errorf("holding %d outcomes for a one minute window", n)
+	}
+}
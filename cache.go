@@ -0,0 +1,201 @@
+package discogs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// Cache stores serialized responses by key. implementations need to be
+// safe for concurrent use, expiry is handled by the client.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+type ResourceKind string
+
+const (
+	ResourceRelease    ResourceKind = "releases"
+	ResourceMaster     ResourceKind = "masters"
+	ResourceArtist     ResourceKind = "artists"
+	ResourceLabel      ResourceKind = "labels"
+	ResourceCollection ResourceKind = "collection"
+	ResourceOther      ResourceKind = "other"
+)
+
+// CacheConfig sets where responses are cached and for how long per kind
+// of resource. kinds without a ttl are never cached.
+type CacheConfig struct {
+	Store Cache
+	TTL   map[ResourceKind]time.Duration
+}
+
+// DefaultCacheTTLs caches database entries for a day, they hardly ever
+// change. collection listings aren't cached, nothing invalidates them when
+// the collection is edited, add a ResourceCollection ttl to opt in.
+func DefaultCacheTTLs() map[ResourceKind]time.Duration {
+	return map[ResourceKind]time.Duration{
+		ResourceRelease: 24 * time.Hour,
+		ResourceMaster:  24 * time.Hour,
+		ResourceArtist:  24 * time.Hour,
+		ResourceLabel:   24 * time.Hour,
+	}
+}
+
+// WithCache caches successful GET responses in front of Do. entries are
+// keyed on the url and the credentials the request was made with, so
+// clients for different users can share a store. mutating calls don't
+// invalidate anything, so a collection ttl, if set, should stay short.
+func WithCache(cfg CacheConfig) options.Option[Client] {
+	return func(c *Client) error {
+		if cfg.Store == nil {
+			return errors.New("cache store is required")
+		}
+		if cfg.TTL == nil {
+			cfg.TTL = DefaultCacheTTLs()
+		}
+		c.cache = &cfg
+		return nil
+	}
+}
+
+type CacheMode int
+
+const (
+	CacheDefault CacheMode = iota
+	// CacheBypass neither reads from nor writes to the cache.
+	CacheBypass
+	// CacheRefresh skips reading the cache but stores the fresh response.
+	CacheRefresh
+)
+
+type cacheModeKey struct{}
+
+// WithCacheMode returns a context that makes every call made with it use
+// mode instead of the regular cache lookup.
+func WithCacheMode(ctx context.Context, mode CacheMode) context.Context {
+	return context.WithValue(ctx, cacheModeKey{}, mode)
+}
+
+func cacheModeFrom(ctx context.Context) CacheMode {
+	mode, _ := ctx.Value(cacheModeKey{}).(CacheMode)
+	return mode
+}
+
+type cacheEntry struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	Expires    time.Time   `json:"expires"`
+}
+
+func (c *Client) cacheLayer(next Handler) Handler {
+	return func(ctx context.Context, req *http.Request) (*Response, error) {
+		if req.Method != http.MethodGet {
+			return next(ctx, req)
+		}
+
+		mode := cacheModeFrom(ctx)
+		ttl, ok := c.cache.TTL[resourceKind(req.URL.Path)]
+		if !ok || ttl <= 0 || mode == CacheBypass {
+			return next(ctx, req)
+		}
+
+		key := c.cacheKey(req)
+
+		if mode != CacheRefresh {
+			if resp, ok := c.cachedResponse(key, req); ok {
+				return resp, nil
+			}
+		}
+
+		resp, err := next(ctx, req)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return resp, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		header := resp.Header.Clone()
+		for _, h := range []string{"x-discogs-ratelimit", "x-discogs-ratelimit-used", "x-discogs-ratelimit-remaining"} {
+			header.Del(h)
+		}
+
+		data, err := json.Marshal(cacheEntry{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       body,
+			Expires:    time.Now().Add(ttl),
+		})
+		if err == nil {
+			c.cache.Store.Set(key, data)
+		}
+
+		return resp, nil
+	}
+}
+
+func (c *Client) cachedResponse(key string, req *http.Request) (*Response, bool) {
+	data, ok := c.cache.Store.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	err := json.Unmarshal(data, &entry)
+	if err != nil || time.Now().After(entry.Expires) {
+		c.cache.Store.Delete(key)
+		return nil, false
+	}
+
+	resp := NewResponse(&http.Response{
+		Status:        http.StatusText(entry.StatusCode),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header,
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	})
+	resp.FromCache = true
+
+	return resp, true
+}
+
+func (c *Client) cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(c.requestIdentity(req) + "\n" + req.Method + "\n" + req.URL.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func resourceKind(path string) ResourceKind {
+	route := routeTemplate(path)
+
+	if strings.HasPrefix(route, "users/{username}/collection") {
+		return ResourceCollection
+	}
+
+	first, _, _ := strings.Cut(route, "/")
+	switch kind := ResourceKind(first); kind {
+	case ResourceRelease, ResourceMaster, ResourceArtist, ResourceLabel:
+		return kind
+	}
+
+	return ResourceOther
+}
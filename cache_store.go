@@ -0,0 +1,198 @@
+package discogs
+
+import (
+	"container/list"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache returns an in-memory store that evicts the least recently
+// used entry once it holds maxEntries.
+func NewMemoryCache(maxEntries int) Cache {
+	return &memoryCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (m *memoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(e)
+
+	return e.Value.(*memoryCacheItem).value, true
+}
+
+func (m *memoryCache) Set(key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		e.Value.(*memoryCacheItem).value = value
+		m.order.MoveToFront(e)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryCacheItem{key: key, value: value})
+
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+func (m *memoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		m.order.Remove(e)
+		delete(m.entries, key)
+	}
+}
+
+// how often Set sweeps the cache directory for expired entries
+const diskSweepInterval = time.Hour
+
+// diskCache keeps one file per entry, named after the key. keys are hex
+// hashes so they are always safe file names.
+type diskCache struct {
+	dir string
+
+	mu    sync.Mutex
+	swept time.Time
+}
+
+// NewDiskCache returns a store keeping entries as files in dir. expired
+// entries are removed when read, and once an hour Set sweeps dir for the
+// ones nobody asks for again. only files named like cache keys are
+// touched, anything else in dir is left alone.
+func NewDiskCache(dir string) (Cache, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	return &diskCache{dir: dir}, nil
+}
+
+func (d *diskCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (d *diskCache) Set(key string, value []byte) {
+	// write to a temp file and rename over the entry so readers never see
+	// half written data
+	f, err := os.CreateTemp(d.dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+
+	_, err = f.Write(value)
+	closeErr := f.Close()
+	if err != nil || closeErr != nil {
+		os.Remove(f.Name())
+		return
+	}
+
+	err = os.Rename(f.Name(), d.path(key))
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	d.mu.Lock()
+	due := time.Since(d.swept) >= diskSweepInterval
+	if due {
+		d.swept = time.Now()
+	}
+	d.mu.Unlock()
+
+	if due {
+		d.sweep(time.Now())
+	}
+}
+
+// sweep removes expired and unreadable entries, and temp files a write
+// that never finished left behind.
+func (d *diskCache) sweep(now time.Time) {
+	files, err := os.ReadDir(d.dir)
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		name := f.Name()
+		path := filepath.Join(d.dir, name)
+
+		if strings.HasPrefix(name, ".tmp-") {
+			info, err := f.Info()
+			if err == nil && now.Sub(info.ModTime()) >= diskSweepInterval {
+				os.Remove(path)
+			}
+			continue
+		}
+
+		if !isCacheKey(name) {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var entry struct {
+			Expires time.Time `json:"expires"`
+		}
+		if json.Unmarshal(data, &entry) != nil || now.After(entry.Expires) {
+			os.Remove(path)
+		}
+	}
+}
+
+// isCacheKey reports whether name could be a key the client made, a hex
+// sha256.
+func isCacheKey(name string) bool {
+	if len(name) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
+func (d *diskCache) Delete(key string) {
+	os.Remove(d.path(key))
+}
+
+func (d *diskCache) path(key string) string {
+	return filepath.Join(d.dir, filepath.Base(key))
+}
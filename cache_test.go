@@ -0,0 +1,288 @@
+package discogs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// cacheServer answers every request with a body counting the requests for
+// that path and authorization, so a cached answer is told apart from a
+// fresh one.
+type cacheServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	hits   map[string]int
+	status int
+}
+
+func newCacheServer(t *testing.T) *cacheServer {
+	t.Helper()
+
+	s := &cacheServer{hits: make(map[string]int), status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		key := r.URL.Path + " " + r.Header.Get("Authorization")
+		s.hits[key]++
+		n, status := s.hits[key], s.status
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Discogs-Ratelimit", "6000")
+		w.Header().Set("X-Discogs-Ratelimit-Used", strconv.Itoa(n))
+		w.Header().Set("X-Discogs-Ratelimit-Remaining", strconv.Itoa(6000-n))
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"id":%d}`, n)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func newCachedClient(t *testing.T, srv *cacheServer, opts ...options.Option[Client]) *Client {
+	t.Helper()
+
+	opts = append([]options.Option[Client]{
+		WithToken("secret"),
+		WithBaseURL(srv.URL),
+		WithRateLimiter(NewRateLimiter(6000)),
+		WithRetryPolicy(RetryPolicy{}),
+		WithCache(CacheConfig{Store: NewMemoryCache(100)}),
+	}, opts...)
+
+	c, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// getID fetches path and returns the id in the body, which counts the
+// requests the server got for it.
+func getID(t *testing.T, c *Client, ctx context.Context, path string, opts ...options.Option[http.Request]) (int, *Response) {
+	t.Helper()
+
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v struct {
+		ID int `json:"id"`
+	}
+	resp, err := c.Do(ctx, req, &v)
+	if err != nil && resp == nil {
+		t.Fatal(err)
+	}
+	return v.ID, resp
+}
+
+func TestCacheHitAndMiss(t *testing.T) {
+	srv := newCacheServer(t)
+	c := newCachedClient(t, srv)
+	ctx := context.Background()
+
+	id, resp := getID(t, c, ctx, "releases/1")
+	if id != 1 || resp.FromCache {
+		t.Fatalf("first call got id %d, from cache %v", id, resp.FromCache)
+	}
+
+	id, resp = getID(t, c, ctx, "releases/1")
+	if id != 1 || !resp.FromCache {
+		t.Errorf("second call got id %d, from cache %v, want the cached first response", id, resp.FromCache)
+	}
+
+	// rate headers describe the call that was made, not the cached one
+	if resp.Rate != (RateLimit{}) || resp.Header.Get("X-Discogs-Ratelimit-Remaining") != "" {
+		t.Errorf("cached response carries rate limit info: %+v %v", resp.Rate, resp.Header)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("cached response lost its other headers: %v", resp.Header)
+	}
+
+	// another url is a miss
+	if id, _ := getID(t, c, ctx, "releases/2"); id != 1 {
+		t.Errorf("releases/2 got id %d from the server, want 1", id)
+	}
+
+	// collection listings aren't cached by default
+	getID(t, c, ctx, "users/bob/collection/folders")
+	if id, _ := getID(t, c, ctx, "users/bob/collection/folders"); id != 2 {
+		t.Errorf("collection listing got id %d, want it fetched again", id)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	srv := newCacheServer(t)
+	c := newCachedClient(t, srv, WithCache(CacheConfig{
+		Store: NewMemoryCache(100),
+		TTL:   map[ResourceKind]time.Duration{ResourceRelease: 20 * time.Millisecond},
+	}))
+	ctx := context.Background()
+
+	getID(t, c, ctx, "releases/1")
+	time.Sleep(30 * time.Millisecond)
+
+	if id, resp := getID(t, c, ctx, "releases/1"); id != 2 || resp.FromCache {
+		t.Errorf("expired entry served, got id %d", id)
+	}
+}
+
+func TestCacheModes(t *testing.T) {
+	srv := newCacheServer(t)
+	c := newCachedClient(t, srv)
+	ctx := context.Background()
+
+	getID(t, c, ctx, "releases/1")
+
+	// bypass goes to the server and leaves the cache as it was
+	if id, resp := getID(t, c, WithCacheMode(ctx, CacheBypass), "releases/1"); id != 2 || resp.FromCache {
+		t.Errorf("bypass got id %d, from cache %v", id, resp.FromCache)
+	}
+	if id, _ := getID(t, c, ctx, "releases/1"); id != 1 {
+		t.Errorf("after a bypass the cache holds id %d, want 1", id)
+	}
+
+	// refresh goes to the server and stores what it got
+	if id, resp := getID(t, c, WithCacheMode(ctx, CacheRefresh), "releases/1"); id != 3 || resp.FromCache {
+		t.Errorf("refresh got id %d, from cache %v", id, resp.FromCache)
+	}
+	if id, resp := getID(t, c, ctx, "releases/1"); id != 3 || !resp.FromCache {
+		t.Errorf("after a refresh the cache holds id %d, want 3", id)
+	}
+}
+
+func TestCacheOnlyStoresOK(t *testing.T) {
+	srv := newCacheServer(t)
+	srv.status = http.StatusNotFound
+	c := newCachedClient(t, srv)
+	ctx := context.Background()
+
+	getID(t, c, ctx, "releases/1")
+	_, resp := getID(t, c, ctx, "releases/1")
+
+	if resp.FromCache || srv.hits["/releases/1 Discogs token=secret"] != 2 {
+		t.Errorf("404 was cached, server got %v", srv.hits)
+	}
+}
+
+func TestCacheKeyedOnCredentials(t *testing.T) {
+	srv := newCacheServer(t)
+	c := newCachedClient(t, srv)
+	ctx := context.Background()
+	alice := NewTokenCredentials("alice")
+
+	getID(t, c, ctx, "releases/1")
+
+	if id, resp := getID(t, c, ctx, "releases/1", WithRequestCredentials(alice)); id != 1 || resp.FromCache {
+		t.Errorf("other credentials got the client's cached response")
+	}
+
+	if id, resp := getID(t, c, WithCredentials(ctx, alice), "releases/1"); id != 1 || !resp.FromCache {
+		t.Errorf("alice's second call got id %d, from cache %v", id, resp.FromCache)
+	}
+
+	if id, resp := getID(t, c, ctx, "releases/1"); id != 1 || !resp.FromCache {
+		t.Errorf("the client's own second call got id %d, from cache %v", id, resp.FromCache)
+	}
+}
+
+func diskKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func encodeEntry(t *testing.T, expires time.Time) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(cacheEntry{StatusCode: 200, Body: []byte(`{}`), Expires: expires})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func writeEntry(t *testing.T, dir string, name string, expires time.Time) {
+	t.Helper()
+
+	err := os.WriteFile(filepath.Join(dir, name), encodeEntry(t, expires), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiskCacheSweep(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	expired, fresh, corrupt := diskKey("expired"), diskKey("fresh"), diskKey("corrupt")
+	writeEntry(t, dir, expired, now.Add(-time.Minute))
+	writeEntry(t, dir, fresh, now.Add(time.Hour))
+	os.WriteFile(filepath.Join(dir, corrupt), []byte("not json"), 0o600)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not ours"), 0o600)
+	os.WriteFile(filepath.Join(dir, ".tmp-old"), nil, 0o600)
+	os.WriteFile(filepath.Join(dir, ".tmp-new"), nil, 0o600)
+	os.Chtimes(filepath.Join(dir, ".tmp-old"), now.Add(-2*time.Hour), now.Add(-2*time.Hour))
+
+	store, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first Set sweeps
+	store.Set(diskKey("new"), encodeEntry(t, now.Add(time.Hour)))
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	for name, want := range map[string]bool{
+		expired:        false,
+		corrupt:        false,
+		".tmp-old":     false,
+		fresh:          true,
+		"notes.txt":    true,
+		".tmp-new":     true,
+		diskKey("new"): true,
+	} {
+		if got := exists(name); got != want {
+			t.Errorf("%s exists %v after the sweep, want %v", name, got, want)
+		}
+	}
+
+	// the next one doesn't, not within the hour
+	writeEntry(t, dir, expired, now.Add(-time.Minute))
+	store.Set(diskKey("other"), encodeEntry(t, now.Add(time.Hour)))
+	if !exists(expired) {
+		t.Error("second Set within the hour swept again")
+	}
+}
+
+func TestDiskCacheThroughClient(t *testing.T) {
+	srv := newCacheServer(t)
+	store, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newCachedClient(t, srv, WithCache(CacheConfig{Store: store}))
+	ctx := context.Background()
+
+	getID(t, c, ctx, "releases/1")
+	if id, resp := getID(t, c, ctx, "releases/1"); id != 1 || !resp.FromCache {
+		t.Errorf("disk cache miss, got id %d", id)
+	}
+}
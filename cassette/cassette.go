@@ -0,0 +1,307 @@
+package cassette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+	"github.com/dkaman/discogs-golang/internal/redact"
+)
+
+type Mode int
+
+const (
+	// ModeReplay answers every request from the cassette and never touches
+	// the network.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request for real and writes each interaction
+	// to the cassette, replacing whatever it held before.
+	ModeRecord
+)
+
+var ErrNoMatch = errors.New("no recorded interaction matches request")
+
+// Interaction is one line of a cassette.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records to or replays from a jsonl
+// cassette. hand Client() to discogs.WithHTTPClient.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	matchers  []Matcher
+	dropped   []string
+
+	mu           sync.Mutex
+	file         *os.File
+	interactions []Interaction
+	used         []bool
+}
+
+func New(path string, mode Mode, opts ...options.Option[Recorder]) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		matchers:  DefaultMatchers(),
+	}
+
+	err := options.Apply(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case ModeRecord:
+		r.file, err = os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("error creating cassette: %w", err)
+		}
+	case ModeReplay:
+		err = r.load()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %d", mode)
+	}
+
+	return r, nil
+}
+
+// WithTransport sets the transport real requests go through while
+// recording, http.DefaultTransport otherwise.
+func WithTransport(rt http.RoundTripper) options.Option[Recorder] {
+	return func(r *Recorder) error {
+		r.transport = rt
+		return nil
+	}
+}
+
+// WithMatchers replaces the default method, path and query matching used
+// to pick a recorded interaction on replay.
+func WithMatchers(m ...Matcher) options.Option[Recorder] {
+	return func(r *Recorder) error {
+		r.matchers = m
+		return nil
+	}
+}
+
+// WithDroppedHeaders leaves the named headers out of recorded requests and
+// responses, on top of the credential headers that are always scrubbed.
+func WithDroppedHeaders(headers ...string) options.Option[Recorder] {
+	return func(r *Recorder) error {
+		r.dropped = append(r.dropped, headers...)
+		return nil
+	}
+}
+
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := Request{
+		Method: req.Method,
+		URL:    scrubURL(req.URL),
+		Header: r.scrubHeader(req.Header),
+		Body:   string(redact.Form(body, req.Header.Get("Content-Type"))),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	return r.record(req, recorded)
+}
+
+// Close flushes a recording cassette, it is a no-op on replay.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+
+	return err
+}
+
+func (r *Recorder) record(req *http.Request, recorded Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	line, err := json.Marshal(Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.scrubHeader(resp.Header),
+			Body:       string(redact.Form(body, resp.Header.Get("Content-Type"))),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding interaction: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil, errors.New("cassette is closed")
+	}
+
+	_, err = r.file.Write(append(line, '\n'))
+	if err != nil {
+		return nil, fmt.Errorf("error writing interaction: %w", err)
+	}
+
+	return resp, nil
+}
+
+// replay hands out recorded interactions in order, each one only once, so
+// the same request made twice gets whatever was recorded for it each time.
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.interactions {
+		if r.used[i] || !r.matches(recorded, in.Request) {
+			continue
+		}
+		r.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoMatch, recorded.Method, recorded.URL)
+}
+
+func (r *Recorder) matches(req Request, rec Request) bool {
+	for _, m := range r.matchers {
+		if !m(req, rec) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) load() error {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return fmt.Errorf("error opening cassette: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var in Interaction
+		err := json.Unmarshal(line, &in)
+		if err != nil {
+			return fmt.Errorf("error decoding cassette line %d: %w", n, err)
+		}
+		r.interactions = append(r.interactions, in)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading cassette: %w", err)
+	}
+
+	r.used = make([]bool, len(r.interactions))
+
+	return nil
+}
+
+// credentials are scrubbed from headers, urls and form bodies before
+// anything is written to disk
+func (r *Recorder) scrubHeader(h http.Header) http.Header {
+	h = redact.Header(h)
+
+	for _, k := range r.dropped {
+		h.Del(k)
+	}
+
+	return h
+}
+
+func scrubURL(u *url.URL) string {
+	scrubbed := *u
+
+	scrubbed.RawQuery = redact.Query(u.Query()).Encode()
+
+	return scrubbed.String()
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
@@ -0,0 +1,279 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dkaman/discogs-golang/oauth"
+)
+
+func get(t *testing.T, c *http.Client, rawURL string) (int, string) {
+	t.Helper()
+
+	resp, err := c.Get(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, string(body)
+}
+
+func TestRecordReplay(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Discogs-Ratelimit-Remaining", "59")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprintf(w, `{"call":%d}`, n)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := rec.Client()
+	status, body := get(t, c, srv.URL+"/releases/1?per_page=5&page=1")
+	if status != 200 || body != `{"call":1}` {
+		t.Fatalf("recording got %d %s", status, body)
+	}
+	get(t, c, srv.URL+"/missing")
+
+	err = rec.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = rep.Client()
+
+	// query order doesn't matter to the default matchers
+	status, body = get(t, c, srv.URL+"/releases/1?page=1&per_page=5")
+	if status != 200 || body != `{"call":1}` {
+		t.Errorf("replay got %d %s", status, body)
+	}
+
+	resp, err := c.Get(srv.URL + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get("X-Discogs-Ratelimit-Remaining") != "59" {
+		t.Errorf("replay got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	_, err = c.Get(srv.URL + "/releases/2")
+	if !errors.Is(err, ErrNoMatch) {
+		t.Errorf("unrecorded request got %v, want ErrNoMatch", err)
+	}
+
+	if n := hits.Load(); n != 2 {
+		t.Errorf("server got %d requests, want only the 2 recorded", n)
+	}
+}
+
+func TestReplayUsesEachInteractionOnce(t *testing.T) {
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, n.Add(1))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		get(t, rec.Client(), srv.URL+"/users/bob/collection/folders")
+	}
+	rec.Close()
+
+	rep, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for range 3 {
+		_, body := get(t, rep.Client(), srv.URL+"/users/bob/collection/folders")
+		got = append(got, body)
+	}
+
+	if strings.Join(got, ",") != "1,2,3" {
+		t.Errorf("replayed %v, want the recorded order 1,2,3", got)
+	}
+
+	_, err = rep.Client().Get(srv.URL + "/users/bob/collection/folders")
+	if !errors.Is(err, ErrNoMatch) {
+		t.Errorf("fourth request got %v, want ErrNoMatch", err)
+	}
+}
+
+func TestRecordRedactsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/request_token":
+			w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+			w.Write([]byte("oauth_token=reqtoken&oauth_token_secret=reqsecret&oauth_callback_confirmed=true"))
+		case "/oauth/access_token":
+			// discogs doesn't always say what it is sending
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("oauth_token=acctoken&oauth_token_secret=accsecret"))
+		default:
+			w.Header().Set("Set-Cookie", "session=cookiesecret")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":1}`))
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	rec, err := New(path, ModeRecord, WithDroppedHeaders("X-Request-Id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &oauth.Config{
+		ConsumerKey:    "consumerkey",
+		ConsumerSecret: "consumersecret",
+		Endpoint: oauth.Endpoint{
+			RequestTokenURL: srv.URL + "/oauth/request_token",
+			AccessTokenURL:  srv.URL + "/oauth/access_token",
+		},
+		HTTPClient: rec.Client(),
+	}
+
+	ctx := context.Background()
+	reqToken, err := config.RequestToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = config.AccessToken(ctx, reqToken, "verifiersecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/oauth/identity?token=querysecret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Discogs token=headersecret")
+	req.Header.Set("X-Request-Id", "dropped-id")
+	resp, err := rec.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	rec.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{
+		"reqtoken", "reqsecret", "acctoken", "accsecret", "consumersecret",
+		"verifiersecret", "querysecret", "headersecret", "cookiesecret", "dropped-id",
+	} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+
+	if !strings.Contains(string(data), "oauth_callback_confirmed=true") {
+		t.Errorf("non secret parameters were lost:\n%s", data)
+	}
+}
+
+func TestMatchBody(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{name: "same json", a: `{"a":1,"b":2}`, b: `{"a":1,"b":2}`, want: true},
+		{name: "key order and whitespace", a: `{"a":1,"b":2}`, b: "{ \"b\": 2,\n \"a\": 1 }", want: true},
+		{name: "different json", a: `{"a":1}`, b: `{"a":2}`, want: false},
+		{name: "same text", a: "a=1", b: "a=1", want: true},
+		{name: "different text", a: "a=1", b: "a=2", want: false},
+		{name: "json against text", a: `{"a":1}`, b: "a=1", want: false},
+		{name: "both empty", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchBody(Request{Body: tt.a}, Request{Body: tt.b})
+			if got != tt.want {
+				t.Errorf("MatchBody(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplayWithMatchBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"jazz", "blues"} {
+		resp, err := rec.Client().Post(srv.URL+"/users/bob/collection/folders", "application/json", strings.NewReader(`{"name":"`+name+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	rec.Close()
+
+	rep, err := New(path, ModeReplay, WithMatchers(MatchMethod, MatchPath, MatchBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// out of recorded order, the body picks the interaction
+	resp, err := rep.Client().Post(srv.URL+"/users/bob/collection/folders", "application/json", strings.NewReader(`{ "name": "blues" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != `{"name":"blues"}` {
+		t.Errorf("replayed %s", body)
+	}
+}
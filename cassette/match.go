@@ -0,0 +1,50 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+)
+
+// Matcher reports whether an incoming request, already scrubbed the same
+// way recorded ones are, matches a recorded request.
+type Matcher func(req Request, recorded Request) bool
+
+func DefaultMatchers() []Matcher {
+	return []Matcher{MatchMethod, MatchPath, MatchQuery}
+}
+
+func MatchMethod(req Request, recorded Request) bool {
+	return req.Method == recorded.Method
+}
+
+func MatchPath(req Request, recorded Request) bool {
+	a, errA := url.Parse(req.URL)
+	b, errB := url.Parse(recorded.URL)
+	if errA != nil || errB != nil {
+		return req.URL == recorded.URL
+	}
+	return a.Path == b.Path
+}
+
+// MatchQuery compares query parameters regardless of their order.
+func MatchQuery(req Request, recorded Request) bool {
+	a, errA := url.Parse(req.URL)
+	b, errB := url.Parse(recorded.URL)
+	if errA != nil || errB != nil {
+		return req.URL == recorded.URL
+	}
+	return a.Query().Encode() == b.Query().Encode()
+}
+
+// MatchBody compares bodies as json when both sides parse as json, so key
+// order and whitespace don't matter, and byte for byte otherwise.
+func MatchBody(req Request, recorded Request) bool {
+	var a, b any
+	if json.Unmarshal([]byte(req.Body), &a) == nil && json.Unmarshal([]byte(recorded.Body), &b) == nil {
+		ja, _ := json.Marshal(a)
+		jb, _ := json.Marshal(b)
+		return bytes.Equal(ja, jb)
+	}
+	return req.Body == recorded.Body
+}
@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/dkaman/discogs-golang/internal/options"
-
-	"golang.org/x/time/rate"
 )
 
 const (
@@ -22,10 +25,35 @@ const (
 
 type Client struct {
 	client      *http.Client
-	rateLimiter *rate.Limiter
-	bearerToken *string
+	rateLimiter RateLimiter
+	auth        authenticator
 	baseURL     *url.URL
 	userAgent   string
+	retryPolicy RetryPolicy
+	middleware  []Middleware
+	handler     Handler
+	logger      *slog.Logger
+	metrics     Metrics
+	tracer      Tracer
+	cache       *CacheConfig
+	coalesce    bool
+	timeout     time.Duration
+	dryRun      bool
+	plan        dryRunPlan
+
+	operationTimeouts map[string]time.Duration
+	scheduler         *scheduler
+	quotas            map[string]float64
+	maxQueueWait      time.Duration
+	customRateLimiter bool
+	breaker           *breaker
+
+	newCredentialLimiter func(*Credentials) RateLimiter
+	rateThresholds       []rateThreshold
+	limiters             credentialLimiters
+
+	identityMu sync.Mutex
+	identity   *Identity
 
 	common service
 
@@ -48,7 +76,11 @@ func New(opts ...options.Option[Client]) (*Client, error) {
 		baseURL:     u,
 		client:      &http.Client{},
 		userAgent:   defaultUserAgent,
-		rateLimiter: rate.NewLimiter(rate.Limit(25.0/60.0), 1),
+		rateLimiter: newAdaptiveLimiter(25),
+		logger:      slog.New(discardHandler{}),
+		metrics:     noopMetrics{},
+		tracer:      noopTracer{},
+		coalesce:    true,
 	}
 
 	c.common.client = c
@@ -61,22 +93,55 @@ func New(opts ...options.Option[Client]) (*Client, error) {
 		return nil, err
 	}
 
+	c.scheduler, err = newScheduler(c.rateLimiter, c.quotas, c.maxQueueWait)
+	if err != nil {
+		return nil, err
+	}
+
+	c.handler = c.buildHandler()
+
 	return c, nil
 }
 
-func WithToken(token string) options.Option[Client] {
+// WithRateLimiter replaces the client's own limiter. pass the same limiter
+// to several clients to have them share one budget.
+func WithRateLimiter(l RateLimiter) options.Option[Client] {
 	return func(c *Client) error {
-		// just writing it this way to show 60 request per minute
-		c.rateLimiter = rate.NewLimiter(rate.Limit(55.0/60.0), 1)
-
-		auth := fmt.Sprintf("Discogs token=%s", token)
-		c.bearerToken = &auth
+		if l == nil {
+			return errors.New("nil rate limiter")
+		}
+		c.rateLimiter = l
+		c.customRateLimiter = true
+		return nil
+	}
+}
 
-		_, err := c.Identity.Get()
+// WithBaseURL points the client somewhere other than api.discogs.com, a
+// proxy or a fake server for instance. request paths are resolved relative
+// to it, so a path prefix is kept.
+func WithBaseURL(baseURL string) options.Option[Client] {
+	return func(c *Client) error {
+		u, err := url.Parse(baseURL)
 		if err != nil {
-			return fmt.Errorf("error getting identity: %w", err)
+			return fmt.Errorf("error parsing base url: %w", err)
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("base url %q must be an absolute url", baseURL)
+		}
+
+		if u.Path != "" && !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
 		}
 
+		c.baseURL = u
+		return nil
+	}
+}
+
+func WithUserAgent(userAgent string) options.Option[Client] {
+	return func(c *Client) error {
+		c.userAgent = userAgent
 		return nil
 	}
 }
@@ -88,7 +153,7 @@ func WithHTTPClient(client *http.Client) options.Option[Client] {
 	}
 }
 
-func (c *Client) NewRequest(method string, urlStr string, body any, opts ...options.Option[http.Request]) (*http.Request, error) {
+func (c *Client) NewRequest(ctx context.Context, method string, urlStr string, body any, opts ...options.Option[http.Request]) (*http.Request, error) {
 	u, err := c.baseURL.Parse(urlStr)
 	if err != nil {
 		return nil, err
@@ -105,15 +170,11 @@ func (c *Client) NewRequest(method string, urlStr string, body any, opts ...opti
 		}
 	}
 
-	req, err := http.NewRequest(method, u.String(), buf)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), buf)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.bearerToken != nil {
-		req.Header.Set("Authorization", *c.bearerToken)
-	}
-
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -122,26 +183,219 @@ func (c *Client) NewRequest(method string, urlStr string, body any, opts ...opti
 		req.Header.Set("User-Agent", c.userAgent)
 	}
 
+	// credentials from the context are kept with the request so Do knows
+	// which limiter to wait on, a request option can still replace them
+	if creds := credentialsFrom(ctx); creds != nil {
+		err = creds.validate()
+		if err != nil {
+			return nil, err
+		}
+
+		updateRequestConfig(req, func(cfg *requestConfig) {
+			cfg.creds = creds
+		})
+	}
+
 	err = options.Apply(req, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	auth := c.auth
+	if creds := requestConfigFrom(req.Context()).creds; creds != nil {
+		auth = creds.auth
+	}
+
+	// the request is authenticated by Do, once per attempt after the
+	// middleware ran, so oauth nonces aren't reused on retries and
+	// signatures cover whatever was done to the url. an option setting its
+	// own credentials is left alone.
+	if auth != nil && req.Header.Get("Authorization") == "" {
+		updateRequestConfig(req, func(cfg *requestConfig) {
+			cfg.auth = auth
+		})
+	}
+
 	return req, nil
 }
 
-func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
-	err := c.rateLimiter.Wait(ctx)
+// Do sends req and decodes the json body into v, or copies it there if v
+// is an io.Writer. ctx governs the whole call, including the http round
+// trip, replacing whatever context req was built with. the body is always
+// drained and closed by the time Do returns, the Response is only good for
+// its status, headers, Rate and Paginator. anything outside the 2xx range
+// comes back as an *ErrorResponse alongside the Response.
+func (c *Client) Do(ctx context.Context, req *http.Request, v any) (*Response, error) {
+	req = bindContext(ctx, req)
+
+	resp, err := c.handler(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("error waiting for rate limiter in http request: %w", err)
+		return nil, err
+	}
+
+	defer func() {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, newErrorResponse(resp)
+	}
+
+	switch v := v.(type) {
+	case nil:
+	case io.Writer:
+		_, err = io.Copy(v, resp.Body)
+	default:
+		err = json.NewDecoder(resp.Body).Decode(v)
+		if errors.Is(err, io.EOF) {
+			// no content, nothing to decode
+			err = nil
+		}
 	}
 
-	resp, err := c.client.Do(req)
+	if err != nil {
+		return resp, fmt.Errorf("error decoding response body: %w", err)
+	}
+
+	return resp, nil
+}
+
+// buildHandler stacks the layers Do runs a request through, outermost
+// first: the cache, coalescing of concurrent GETs, user middleware, then
+// send with its retries.
+func (c *Client) buildHandler() Handler {
+	h := Handler(c.send)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+
+	if c.coalesce {
+		h = c.coalesceLayer(h)
+	}
+
+	if c.cache != nil {
+		h = c.cacheLayer(h)
+	}
+
+	// outermost, a planned call shouldn't touch anything, the rate budget
+	// or the caller's middleware included
+	h = c.dryRunLayer(h)
+
+	return h
+}
+
+func (c *Client) send(ctx context.Context, req *http.Request) (*Response, error) {
+	policy := c.retryPolicy
+	if cfg := requestConfigFrom(req.Context()); cfg.retry != nil {
+		policy = *cfg.retry
+	}
+
+	sched, err := c.schedulerFor(req)
 	if err != nil {
 		return nil, err
 	}
 
-	response := newResponse(resp)
+	for attempt := 1; ; attempt++ {
+		// ask the breaker first, an open one shouldn't cost rate budget
+		probe, err := c.breakerAllow(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		waitStart := time.Now()
+		err = sched.Wait(ctx)
+		waited := time.Since(waitStart)
+		c.logLimiterWait(ctx, req, waited)
+		c.metrics.ObserveLimiterWait(waited)
+		if err != nil {
+			c.breakerSkip(probe)
+			return nil, fmt.Errorf("error waiting for rate limiter in http request: %w", err)
+		}
+
+		if attempt > 1 && req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				c.breakerSkip(probe)
+				return nil, fmt.Errorf("error rewinding request body for retry: %w", err)
+			}
+		}
+
+		if auth := requestConfigFrom(req.Context()).auth; auth != nil {
+			err = auth.authenticate(req)
+			if err != nil {
+				c.breakerSkip(probe)
+				return nil, fmt.Errorf("error authenticating request: %w", err)
+			}
+		}
+
+		resp, elapsed, err := c.roundTrip(ctx, req, attempt, sched)
+		c.breakerRecord(ctx, probe, resp, err)
+
+		if !policy.shouldRetry(req, resp, err, attempt) {
+			if err != nil {
+				c.logRequest(ctx, req, nil, elapsed, err)
+				return nil, err
+			}
+
+			response := NewResponse(resp)
+			c.logRequest(ctx, req, response, elapsed, nil)
+
+			return response, nil
+		}
+
+		delay := policy.backoff(attempt, resp)
+		c.logRetry(ctx, req, resp, err, attempt, delay)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		err = sleep(ctx, delay)
+		if err != nil {
+			return nil, fmt.Errorf("error waiting to retry http request: %w", err)
+		}
+	}
+}
+
+// roundTrip makes a single http call, wrapped in its own span and reported
+// to metrics and the rate limiter the request waited on.
+func (c *Client) roundTrip(ctx context.Context, req *http.Request, attempt int, sched *scheduler) (resp *http.Response, elapsed time.Duration, err error) {
+	route := routeTemplate(req.URL.Path)
+
+	ctx, span := c.tracer.Start(ctx, "HTTP "+req.Method,
+		Attribute{Key: "http.method", Value: req.Method},
+		Attribute{Key: "http.route", Value: route},
+		Attribute{Key: "http.url", Value: redactURL(req.URL)},
+		Attribute{Key: "discogs.attempt", Value: attempt},
+	)
+	defer func() { span.End(err) }()
+
+	if p, ok := c.tracer.(TracePropagator); ok {
+		p.Inject(ctx, req.Header)
+	}
+
+	start := time.Now()
+	resp, err = c.client.Do(req)
+	elapsed = time.Since(start)
+
+	if err != nil {
+		c.metrics.ObserveRequest(route, req.Method, 0, elapsed)
+		return
+	}
+
+	span.SetAttributes(Attribute{Key: "http.status_code", Value: resp.StatusCode})
+	c.metrics.ObserveRequest(route, req.Method, resp.StatusCode, elapsed)
+
+	info := parseRateLimit(resp.Header)
+	if info.Limit > 0 {
+		c.metrics.ObserveRateLimit(info)
+		c.observeRate(sched, info)
+	}
+
+	if o, ok := sched.limiter.(RateObserver); ok {
+		o.Observe(info)
+	}
 
-	return response, nil
+	return
 }
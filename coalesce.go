@@ -0,0 +1,109 @@
+package discogs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// WithCoalescing turns the deduplication of concurrent identical GETs on or
+// off, it is on by default. while a GET is in flight any other GET for the
+// same url and credentials waits for it instead of spending another rate
+// limit token. every caller gets its own copy of the buffered body to
+// decode, so results never alias between goroutines. anything that isn't a
+// GET always goes out on its own.
+func WithCoalescing(enabled bool) options.Option[Client] {
+	return func(c *Client) error {
+		c.coalesce = enabled
+		return nil
+	}
+}
+
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done chan struct{}
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+func (c *Client) coalesceLayer(next Handler) Handler {
+	group := &flightGroup{calls: make(map[string]*flight)}
+
+	return func(ctx context.Context, req *http.Request) (*Response, error) {
+		if req.Method != http.MethodGet {
+			return next(ctx, req)
+		}
+
+		key := c.cacheKey(req)
+
+		group.mu.Lock()
+		if f, ok := group.calls[key]; ok {
+			group.mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-f.done:
+			}
+
+			// the leader gave up because of its own context, that says
+			// nothing about ours so go ahead on our own
+			if errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded) {
+				return next(ctx, req)
+			}
+
+			return f.response(req)
+		}
+
+		f := &flight{done: make(chan struct{})}
+		group.calls[key] = f
+		group.mu.Unlock()
+
+		resp, err := next(ctx, req)
+		if err == nil {
+			// keep our own copy, the leader is free to mess with its own
+			// response once we hand it back
+			snapshot := *resp.Response
+			snapshot.Header = resp.Header.Clone()
+			f.resp = &snapshot
+			f.body, f.err = io.ReadAll(resp.Body)
+			resp.Body = io.NopCloser(bytes.NewReader(f.body))
+		} else {
+			f.err = err
+		}
+
+		group.mu.Lock()
+		delete(group.calls, key)
+		group.mu.Unlock()
+		close(f.done)
+
+		if err == nil && f.err != nil {
+			return nil, f.err
+		}
+
+		return resp, err
+	}
+}
+
+func (f *flight) response(req *http.Request) (*Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(f.body))
+	resp.Request = req
+
+	return NewResponse(&resp), nil
+}
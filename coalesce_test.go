@@ -0,0 +1,301 @@
+package discogs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// blockingHandler counts its calls and holds each one until release is
+// closed.
+type blockingHandler struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{release: make(chan struct{})}
+}
+
+func (h *blockingHandler) handle(ctx context.Context, req *http.Request) (*Response, error) {
+	h.calls.Add(1)
+
+	select {
+	case <-h.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return okResponse(req, `{"id":1,"title":"Kind of Blue"}`), nil
+}
+
+// waitCalls waits for n calls to have reached the handler.
+func (h *blockingHandler) waitCalls(t *testing.T, n int32) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for h.calls.Load() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d calls reached the handler, want %d", h.calls.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func okResponse(req *http.Request, body string) *Response {
+	return NewResponse(&http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	})
+}
+
+func newCoalesceTest(t *testing.T) (*Client, func(ctx context.Context, method string, opts ...options.Option[http.Request]) *http.Request) {
+	t.Helper()
+
+	c, err := New(WithToken("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func(ctx context.Context, method string, opts ...options.Option[http.Request]) *http.Request {
+		t.Helper()
+
+		req, err := c.NewRequest(ctx, method, "releases/1", nil, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	return c, newRequest
+}
+
+type result struct {
+	req  *http.Request
+	resp *Response
+	err  error
+}
+
+func TestCoalesceConcurrentGets(t *testing.T) {
+	c, newRequest := newCoalesceTest(t)
+	next := newBlockingHandler()
+	h := c.coalesceLayer(next.handle)
+
+	const n = 10
+	results := make(chan result, n)
+
+	ctx := context.Background()
+	for range n {
+		req := newRequest(ctx, http.MethodGet)
+		go func() {
+			resp, err := h(ctx, req)
+			results <- result{req: req, resp: resp, err: err}
+		}()
+	}
+
+	// the first call is in flight, give the rest time to join it
+	next.waitCalls(t, 1)
+	time.Sleep(50 * time.Millisecond)
+	close(next.release)
+
+	var bodies []string
+	for range n {
+		r := <-results
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+
+		if r.resp.Request != r.req {
+			t.Error("response carries another caller's request")
+		}
+
+		// mess with what we got, nobody else may see it
+		r.resp.Header.Set("Content-Type", "text/plain")
+
+		body, err := io.ReadAll(r.resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, string(body))
+	}
+
+	if calls := next.calls.Load(); calls != 1 {
+		t.Errorf("%d round trips for %d identical GETs, want 1", calls, n)
+	}
+
+	for i, body := range bodies {
+		if body != `{"id":1,"title":"Kind of Blue"}` {
+			t.Errorf("caller %d read %q", i, body)
+		}
+	}
+}
+
+func TestCoalesceFollowersGetTheirOwnCopy(t *testing.T) {
+	c, newRequest := newCoalesceTest(t)
+	next := newBlockingHandler()
+	h := c.coalesceLayer(next.handle)
+
+	ctx := context.Background()
+	results := make(chan result, 3)
+	for range 3 {
+		req := newRequest(ctx, http.MethodGet)
+		go func() {
+			resp, err := h(ctx, req)
+			results <- result{req: req, resp: resp, err: err}
+		}()
+	}
+
+	next.waitCalls(t, 1)
+	time.Sleep(50 * time.Millisecond)
+	close(next.release)
+
+	a, b, d := <-results, <-results, <-results
+	for _, r := range []result{a, b, d} {
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+	}
+
+	// one caller reading and changing its response leaves the others alone
+	io.ReadAll(a.resp.Body)
+	a.resp.Header.Set("X-Changed", "1")
+
+	for _, r := range []result{b, d} {
+		if r.resp.Header.Get("X-Changed") != "" {
+			t.Error("header change leaked between callers")
+		}
+
+		body, _ := io.ReadAll(r.resp.Body)
+		if string(body) != `{"id":1,"title":"Kind of Blue"}` {
+			t.Errorf("caller read %q after another drained its body", body)
+		}
+	}
+}
+
+func TestCoalesceOnlyGets(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			c, newRequest := newCoalesceTest(t)
+			next := newBlockingHandler()
+			h := c.coalesceLayer(next.handle)
+
+			ctx := context.Background()
+			var wg sync.WaitGroup
+			for range 3 {
+				req := newRequest(ctx, method)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					h(ctx, req)
+				}()
+			}
+
+			// all three have to be in flight at once
+			next.waitCalls(t, 3)
+			close(next.release)
+			wg.Wait()
+		})
+	}
+}
+
+func TestCoalesceFollowerRetriesWhenLeaderCancelled(t *testing.T) {
+	c, newRequest := newCoalesceTest(t)
+
+	var calls atomic.Int32
+	leaderIn := make(chan struct{})
+	next := func(ctx context.Context, req *http.Request) (*Response, error) {
+		if calls.Add(1) == 1 {
+			close(leaderIn)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return okResponse(req, `{"id":1}`), nil
+	}
+	h := c.coalesceLayer(next)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := h(leaderCtx, newRequest(leaderCtx, http.MethodGet))
+		leaderErr <- err
+	}()
+	<-leaderIn
+
+	ctx := context.Background()
+	follower := make(chan result)
+	go func() {
+		resp, err := h(ctx, newRequest(ctx, http.MethodGet))
+		follower <- result{resp: resp, err: err}
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader got %v, want context.Canceled", err)
+	}
+
+	r := <-follower
+	if r.err != nil {
+		t.Fatalf("follower got %v, want it to go ahead on its own", r.err)
+	}
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d round trips, want the leader's and the follower's own", n)
+	}
+}
+
+func TestCoalesceFollowerCancelled(t *testing.T) {
+	c, newRequest := newCoalesceTest(t)
+	next := newBlockingHandler()
+	defer close(next.release)
+	h := c.coalesceLayer(next.handle)
+
+	go h(context.Background(), newRequest(context.Background(), http.MethodGet))
+	next.waitCalls(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := h(ctx, newRequest(ctx, http.MethodGet))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("follower got %v, want its own deadline", err)
+	}
+}
+
+func TestCoalesceKeyedOnCredentials(t *testing.T) {
+	c, newRequest := newCoalesceTest(t)
+	next := newBlockingHandler()
+	h := c.coalesceLayer(next.handle)
+
+	ctx := context.Background()
+	reqs := []*http.Request{
+		newRequest(ctx, http.MethodGet),
+		newRequest(ctx, http.MethodGet, WithRequestCredentials(NewTokenCredentials("alice"))),
+		newRequest(WithCredentials(ctx, NewTokenCredentials("bob")), http.MethodGet),
+	}
+
+	var wg sync.WaitGroup
+	for _, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h(req.Context(), req)
+		}()
+	}
+
+	// three sets of credentials, three flights
+	next.waitCalls(t, 3)
+	close(next.release)
+	wg.Wait()
+}
@@ -2,8 +2,6 @@ package discogs
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -75,20 +73,20 @@ type GetFoldersResponse struct {
 }
 
 func (s *CollectionService) ListFolders(ctx context.Context, username string) (folders []Folder, err error) {
-	u := fmt.Sprintf("users/%s/collection/folders", username)
+	ctx, end := s.client.startOperation(ctx, "CollectionService.ListFolders",
+		Attribute{Key: "discogs.username", Value: username},
+	)
+	defer end(&err)
 
-	req, err := s.client.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return
-	}
+	u := fmt.Sprintf("users/%s/collection/folders", username)
 
-	resp, err := s.client.Do(ctx, req)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return
 	}
 
 	var r GetFoldersResponse
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	_, err = s.client.Do(ctx, req, &r)
 	if err != nil {
 		return
 	}
@@ -99,6 +97,16 @@ func (s *CollectionService) ListFolders(ctx context.Context, username string) (f
 }
 
 func (s *CollectionService) CreateFolder(ctx context.Context, username string, folderName string) (folder *Folder, err error) {
+	ctx, end := s.client.startOperation(ctx, "CollectionService.CreateFolder",
+		Attribute{Key: "discogs.username", Value: username},
+	)
+	defer end(&err)
+
+	err = s.client.requireUserAuth(ctx)
+	if err != nil {
+		return
+	}
+
 	u := fmt.Sprintf("users/%s/collection/folders", username)
 
 	body := struct {
@@ -109,169 +117,130 @@ func (s *CollectionService) CreateFolder(ctx context.Context, username string, f
 		Name:     folderName,
 	}
 
-	req, err := s.client.NewRequest(http.MethodPost, u, body)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, u, body)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := s.client.Do(ctx, req)
+	var f Folder
+	_, err = s.client.Do(ctx, req, &f)
 	if err != nil {
 		return nil, err
 	}
-
-	if resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("did not recieve 201 from server, got %d", resp.StatusCode)
-	}
-
-	var f Folder
-	err = json.NewDecoder(resp.Body).Decode(&f)
 	folder = &f
 
 	return
 }
 
 func (s *CollectionService) GetFolder(ctx context.Context, username string, folderID int) (folder *Folder, err error) {
+	ctx, end := s.client.startOperation(ctx, "CollectionService.GetFolder",
+		Attribute{Key: "discogs.username", Value: username},
+		Attribute{Key: "discogs.folder_id", Value: folderID},
+	)
+	defer end(&err)
+
 	u := fmt.Sprintf("users/%s/collection/folders/%d", username, folderID)
 
-	req, err := s.client.NewRequest(http.MethodGet, u, nil)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := s.client.Do(ctx, req)
+	var f Folder
+	_, err = s.client.Do(ctx, req, &f)
 	if err != nil {
 		return nil, err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("did not receive 200 from server, got %d", resp.StatusCode)
-	}
-
-	var f Folder
-	err = json.NewDecoder(resp.Body).Decode(&f)
 	folder = &f
 
 	return
 }
 
 func (s *CollectionService) EditFolder(ctx context.Context, username string, folderID int, newFolder Folder) (folder *Folder, err error) {
-	u := fmt.Sprintf("users/%s/collection/folders/%d", username, folderID)
+	ctx, end := s.client.startOperation(ctx, "CollectionService.EditFolder",
+		Attribute{Key: "discogs.username", Value: username},
+		Attribute{Key: "discogs.folder_id", Value: folderID},
+	)
+	defer end(&err)
 
-	req, err := s.client.NewRequest(http.MethodPost, u, newFolder)
+	err = s.client.requireUserAuth(ctx)
 	if err != nil {
-		return nil, err
+		return
 	}
 
-	resp, err := s.client.Do(ctx, req)
+	u := fmt.Sprintf("users/%s/collection/folders/%d", username, folderID)
+
+	req, err := s.client.NewRequest(ctx, http.MethodPost, u, newFolder)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non 200 from server, got %d", resp.StatusCode)
-	}
-
 	var f Folder
-	err = json.NewDecoder(resp.Body).Decode(&f)
+	_, err = s.client.Do(ctx, req, &f)
+	if err != nil {
+		return nil, err
+	}
 	folder = &f
 
 	return
 }
 
 func (s *CollectionService) DeleteFolder(ctx context.Context, username string, folderID int) (err error) {
-	u := fmt.Sprintf("users/%s/collection/folders/%d", username, folderID)
+	ctx, end := s.client.startOperation(ctx, "CollectionService.DeleteFolder",
+		Attribute{Key: "discogs.username", Value: username},
+		Attribute{Key: "discogs.folder_id", Value: folderID},
+	)
+	defer end(&err)
 
-	req, err := s.client.NewRequest(http.MethodDelete, u, nil)
+	err = s.client.requireUserAuth(ctx)
 	if err != nil {
 		return
 	}
 
-	resp, err := s.client.Do(ctx, req)
+	u := fmt.Sprintf("users/%s/collection/folders/%d", username, folderID)
+
+	req, err := s.client.NewRequest(ctx, http.MethodDelete, u, nil)
 	if err != nil {
 		return
 	}
 
-	if resp.StatusCode != http.StatusNoContent { // 204
-		err = fmt.Errorf("obtained non-204 on delete: got %d", resp.StatusCode)
-	}
+	_, err = s.client.Do(ctx, req, nil)
 
 	return
 }
 
 type GetFolderByReleaseResponse releaseResponse
 
-func (s *CollectionService) GetFolderByRelease(ctx context.Context, username string, releaseID int) (releases []ReleaseInstance, err error) {
-	u := fmt.Sprintf("users/%s/collection/releases/%d", username, releaseID)
-
-	req, err := s.client.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := s.client.Do(ctx, req)
-	if err != nil {
-		return nil, err
-	}
+func (s *CollectionService) GetFolderByRelease(ctx context.Context, username string, releaseID int, opts *ListOptions) (releases []ReleaseInstance, err error) {
+	ctx, end := s.client.startOperation(ctx, "CollectionService.GetFolderByRelease",
+		Attribute{Key: "discogs.username", Value: username},
+		Attribute{Key: "discogs.release_id", Value: releaseID},
+	)
+	defer end(&err)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non 200 from server, got %d", resp.StatusCode)
-	}
+	u := fmt.Sprintf("users/%s/collection/releases/%d", username, releaseID)
 
-	first, pager, err := NewPager[GetFolderByReleaseResponse](resp, s.client)
-	if err != nil {
-		return nil, err
-	}
-	releases = append(releases, first.Releases...)
-
-	for {
-		next, err := pager.Next(ctx)
-		if errors.Is(err, ErrPageDone) {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		releases = append(releases, next.Releases...)
-	}
+	releases, err = listAll(ctx, s.client, u, opts, func(page *GetFolderByReleaseResponse) []ReleaseInstance {
+		return page.Releases
+	})
 
 	return
 }
 
 type GetReleaseByFolderResponse releaseResponse
 
-func (s *CollectionService) GetReleasesByFolder(ctx context.Context, username string, folderID int) (releases []ReleaseInstance, err error) {
-	u := fmt.Sprintf("users/%s/collection/folders/%d/releases", username, folderID)
-
-	req, err := s.client.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := s.client.Do(ctx, req)
-	if err != nil {
-		return nil, err
-	}
+func (s *CollectionService) GetReleasesByFolder(ctx context.Context, username string, folderID int, opts *ListOptions) (releases []ReleaseInstance, err error) {
+	ctx, end := s.client.startOperation(ctx, "CollectionService.GetReleasesByFolder",
+		Attribute{Key: "discogs.username", Value: username},
+		Attribute{Key: "discogs.folder_id", Value: folderID},
+	)
+	defer end(&err)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non 200 from server, got %d", resp.StatusCode)
-	}
+	u := fmt.Sprintf("users/%s/collection/folders/%d/releases", username, folderID)
 
-	first, pager, err := NewPager[GetReleaseByFolderResponse](resp, s.client)
-	if err != nil {
-		return nil, err
-	}
-	releases = append(releases, first.Releases...)
-
-	for {
-		next, err := pager.Next(ctx)
-		if errors.Is(err, ErrPageDone) {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		releases = append(releases, next.Releases...)
-	}
+	releases, err = listAll(ctx, s.client, u, opts, func(page *GetReleaseByFolderResponse) []ReleaseInstance {
+		return page.Releases
+	})
 
 	return
 }
@@ -279,25 +248,27 @@ func (s *CollectionService) GetReleasesByFolder(ctx context.Context, username st
 type AddReleaseToFolderResponse Instance
 
 func (s *CollectionService) AddReleaseToFolder(ctx context.Context, username string, folderID int, releaseID int) (instance Instance, err error) {
-	u := fmt.Sprintf("users/%s/collection/folders/%d/releases/%d", username, folderID, releaseID)
+	ctx, end := s.client.startOperation(ctx, "CollectionService.AddReleaseToFolder",
+		Attribute{Key: "discogs.username", Value: username},
+		Attribute{Key: "discogs.folder_id", Value: folderID},
+		Attribute{Key: "discogs.release_id", Value: releaseID},
+	)
+	defer end(&err)
 
-	req, err := s.client.NewRequest(http.MethodPost, u, nil)
+	err = s.client.requireUserAuth(ctx)
 	if err != nil {
 		return
 	}
 
-	resp, err := s.client.Do(ctx, req)
-	if err != nil {
-		return
-	}
+	u := fmt.Sprintf("users/%s/collection/folders/%d/releases/%d", username, folderID, releaseID)
 
-	if resp.StatusCode != http.StatusCreated { // 201
-		err = fmt.Errorf("did not receive status 201 from server, got %d", resp.StatusCode)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, u, nil)
+	if err != nil {
 		return
 	}
 
 	var r AddReleaseToFolderResponse
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	_, err = s.client.Do(ctx, req, &r)
 	if err != nil {
 		return
 	}
@@ -308,6 +279,19 @@ func (s *CollectionService) AddReleaseToFolder(ctx context.Context, username str
 }
 
 func (s *CollectionService) ChangeRatingOfRelease(ctx context.Context, username string, folderID int, releaseID int, instanceID int, rating int) (err error) {
+	ctx, end := s.client.startOperation(ctx, "CollectionService.ChangeRatingOfRelease",
+		Attribute{Key: "discogs.username", Value: username},
+		Attribute{Key: "discogs.folder_id", Value: folderID},
+		Attribute{Key: "discogs.release_id", Value: releaseID},
+		Attribute{Key: "discogs.instance_id", Value: instanceID},
+	)
+	defer end(&err)
+
+	err = s.client.requireUserAuth(ctx)
+	if err != nil {
+		return
+	}
+
 	u := fmt.Sprintf("users/%s/collection/folders/%d/releases/%d/instances/%d", username, folderID, releaseID, instanceID)
 
 	body := struct {
@@ -316,45 +300,38 @@ func (s *CollectionService) ChangeRatingOfRelease(ctx context.Context, username
 		Rating: rating,
 	}
 
-	req, err := s.client.NewRequest(http.MethodPost, u, body)
+	req, err := s.client.NewRequest(ctx, http.MethodPost, u, body)
 	if err != nil {
 		return
 	}
 
-	resp, err := s.client.Do(ctx, req)
-	if err != nil {
-		return
-	}
-
-	if resp.StatusCode != http.StatusNoContent { // 204
-		err = fmt.Errorf("did not receive status 204 from server, got %d", resp.StatusCode)
-		return
-	}
+	_, err = s.client.Do(ctx, req, nil)
 
 	return
 }
 
 func (s *CollectionService) RemoveReleaseFromFolder(ctx context.Context, username string, folderID int, releaseID int, instanceID int) (err error) {
-	u := fmt.Sprintf("users/%s/collection/folders/%d/releases/%d/instances/%d", username, folderID, releaseID, instanceID)
+	ctx, end := s.client.startOperation(ctx, "CollectionService.RemoveReleaseFromFolder",
+		Attribute{Key: "discogs.username", Value: username},
+		Attribute{Key: "discogs.folder_id", Value: folderID},
+		Attribute{Key: "discogs.release_id", Value: releaseID},
+		Attribute{Key: "discogs.instance_id", Value: instanceID},
+	)
+	defer end(&err)
 
-	req, err := s.client.NewRequest(http.MethodPost, u, nil)
+	err = s.client.requireUserAuth(ctx)
 	if err != nil {
 		return
 	}
 
-	resp, err := s.client.Do(ctx, req)
+	u := fmt.Sprintf("users/%s/collection/folders/%d/releases/%d/instances/%d", username, folderID, releaseID, instanceID)
+
+	req, err := s.client.NewRequest(ctx, http.MethodDelete, u, nil)
 	if err != nil {
 		return
 	}
 
-	switch resp.StatusCode {
-	case http.StatusNoContent: // 204
-		err = nil
-	case http.StatusForbidden: // 403
-		err = fmt.Errorf("unauthorized to edit folder %d", folderID)
-	default:
-		err = fmt.Errorf("did not receive 204 from server, got %d", resp.StatusCode)
-	}
+	_, err = s.client.Do(ctx, req, nil)
 
 	return
 }
@@ -364,24 +341,20 @@ type ListCustomFieldsResponse struct {
 }
 
 func (s *CollectionService) ListCustomFields(ctx context.Context, username string) (fields []Field, err error) {
-	u := fmt.Sprintf("users/%s/collection/fields", username)
+	ctx, end := s.client.startOperation(ctx, "CollectionService.ListCustomFields",
+		Attribute{Key: "discogs.username", Value: username},
+	)
+	defer end(&err)
 
-	req, err := s.client.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return nil, err
-	}
+	u := fmt.Sprintf("users/%s/collection/fields", username)
 
-	resp, err := s.client.Do(ctx, req)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non 200 from server, got %d", resp.StatusCode)
-	}
-
 	var r ListCustomFieldsResponse
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	_, err = s.client.Do(ctx, req, &r)
 	if err != nil {
 		return nil, err
 	}
@@ -391,44 +364,58 @@ func (s *CollectionService) ListCustomFields(ctx context.Context, username strin
 }
 
 func (s *CollectionService) EditCustomFields(ctx context.Context, username string, folderID int, releaseID int, instanceID int, fieldID int, value string) (err error) {
-	u := fmt.Sprintf("users/%s/collection/folders/%d/releases/%d/instances/%d/fields/%d", username, folderID, releaseID, instanceID, fieldID)
+	ctx, end := s.client.startOperation(ctx, "CollectionService.EditCustomFields",
+		Attribute{Key: "discogs.username", Value: username},
+		Attribute{Key: "discogs.folder_id", Value: folderID},
+		Attribute{Key: "discogs.release_id", Value: releaseID},
+		Attribute{Key: "discogs.instance_id", Value: instanceID},
+		Attribute{Key: "discogs.field_id", Value: fieldID},
+	)
+	defer end(&err)
 
-	req, err := s.client.NewRequest(http.MethodPost, u, nil)
+	err = s.client.requireUserAuth(ctx)
 	if err != nil {
 		return
 	}
 
-	resp, err := s.client.Do(ctx, req)
+	u := fmt.Sprintf("users/%s/collection/folders/%d/releases/%d/instances/%d/fields/%d", username, folderID, releaseID, instanceID, fieldID)
+
+	body := struct {
+		Value string `json:"value"`
+	}{
+		Value: value,
+	}
+
+	req, err := s.client.NewRequest(ctx, http.MethodPost, u, body)
 	if err != nil {
 		return
 	}
 
-	if resp.StatusCode != http.StatusNoContent { // 204
-		err = fmt.Errorf("did not receive 204 from server, got %d", resp.StatusCode)
-	}
+	_, err = s.client.Do(ctx, req, nil)
 
 	return
 }
 
 func (s *CollectionService) GetCollectionValue(ctx context.Context, username string) (value *Value, err error) {
-	u := fmt.Sprintf("users/%s/collection/value", username)
+	ctx, end := s.client.startOperation(ctx, "CollectionService.GetCollectionValue",
+		Attribute{Key: "discogs.username", Value: username},
+	)
+	defer end(&err)
 
-	req, err := s.client.NewRequest(http.MethodGet, u, nil)
+	err = s.client.requireUserAuth(ctx)
 	if err != nil {
 		return
 	}
 
-	resp, err := s.client.Do(ctx, req)
+	u := fmt.Sprintf("users/%s/collection/value", username)
+
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non 200 from server, got %d", resp.StatusCode)
-	}
-
 	var r Value
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	_, err = s.client.Do(ctx, req, &r)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,238 @@
+package discogs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// Config is everything a client can be set up from without writing code.
+// it is read from a json file and DISCOGS_* environment variables, the
+// environment winning over the file:
+//
+//	DISCOGS_CONFIG           path to the json config file
+//	DISCOGS_TOKEN            personal access token
+//	DISCOGS_CONSUMER_KEY     application consumer key
+//	DISCOGS_CONSUMER_SECRET  application consumer secret
+//	DISCOGS_BASE_URL         api base url
+//	DISCOGS_USER_AGENT       user agent sent with every request
+//	DISCOGS_RATE_LIMIT       requests per minute to start the limiter at
+//	DISCOGS_CACHE_DIR        directory for the on-disk response cache
+//	DISCOGS_TIMEOUT          default operation timeout, like 30s
+type Config struct {
+	Token          string        `json:"token"`
+	ConsumerKey    string        `json:"consumer_key"`
+	ConsumerSecret string        `json:"consumer_secret"`
+	BaseURL        string        `json:"base_url"`
+	UserAgent      string        `json:"user_agent"`
+	RateLimit      float64       `json:"rate_limit"`
+	CacheDir       string        `json:"cache_dir"`
+	Timeout        time.Duration `json:"-"`
+}
+
+// LoadConfig reads the config file at path, or the one DISCOGS_CONFIG
+// points to if path is empty, then applies the environment on top. with
+// neither a path nor DISCOGS_CONFIG only the environment is used.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	if path == "" {
+		path = os.Getenv("DISCOGS_CONFIG")
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+
+		err = json.Unmarshal(data, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+		}
+	}
+
+	err := cfg.loadEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// NewFromEnv builds a client from LoadConfig(""), opts are applied after
+// the ones coming from the config so they can override it.
+func NewFromEnv(opts ...options.Option[Client]) (*Client, error) {
+	cfg, err := LoadConfig("")
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.New(opts...)
+}
+
+func (cfg *Config) New(opts ...options.Option[Client]) (*Client, error) {
+	cfgOpts, err := cfg.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(append(cfgOpts, opts...)...)
+}
+
+func (cfg *Config) Options() ([]options.Option[Client], error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []options.Option[Client]
+
+	if cfg.BaseURL != "" {
+		opts = append(opts, WithBaseURL(cfg.BaseURL))
+	}
+
+	if cfg.UserAgent != "" {
+		opts = append(opts, WithUserAgent(cfg.UserAgent))
+	}
+
+	switch {
+	case cfg.Token != "":
+		opts = append(opts, WithToken(cfg.Token))
+	case cfg.ConsumerKey != "":
+		opts = append(opts, WithConsumerCredentials(cfg.ConsumerKey, cfg.ConsumerSecret))
+	}
+
+	if cfg.RateLimit > 0 {
+		opts = append(opts, WithRateLimiter(NewRateLimiter(cfg.RateLimit)))
+	}
+
+	if cfg.CacheDir != "" {
+		store, err := NewDiskCache(cfg.CacheDir)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithCache(CacheConfig{Store: store}))
+	}
+
+	if cfg.Timeout > 0 {
+		opts = append(opts, WithTimeout(cfg.Timeout))
+	}
+
+	return opts, nil
+}
+
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	if cfg.Token != "" && (cfg.ConsumerKey != "" || cfg.ConsumerSecret != "") {
+		errs = append(errs, errors.New("set either a token or consumer credentials, not both"))
+	}
+
+	if (cfg.ConsumerKey == "") != (cfg.ConsumerSecret == "") {
+		errs = append(errs, errors.New("consumer key and secret must be set together"))
+	}
+
+	if cfg.BaseURL != "" {
+		u, err := url.Parse(cfg.BaseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("base url %q must be an absolute url", cfg.BaseURL))
+		}
+	}
+
+	if cfg.RateLimit < 0 {
+		errs = append(errs, fmt.Errorf("rate limit must not be negative, got %v", cfg.RateLimit))
+	}
+
+	if cfg.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("timeout must not be negative, got %s", cfg.Timeout))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// the timeout goes in the file as a duration string, like the environment
+func (cfg *Config) UnmarshalJSON(data []byte) error {
+	type plain Config
+	var raw struct {
+		*plain
+		Timeout string `json:"timeout"`
+	}
+	raw.plain = (*plain)(cfg)
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	if raw.Timeout != "" {
+		cfg.Timeout, err = time.ParseDuration(raw.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (cfg *Config) loadEnv() error {
+	for env, field := range map[string]*string{
+		"DISCOGS_TOKEN":           &cfg.Token,
+		"DISCOGS_CONSUMER_KEY":    &cfg.ConsumerKey,
+		"DISCOGS_CONSUMER_SECRET": &cfg.ConsumerSecret,
+		"DISCOGS_BASE_URL":        &cfg.BaseURL,
+		"DISCOGS_USER_AGENT":      &cfg.UserAgent,
+		"DISCOGS_CACHE_DIR":       &cfg.CacheDir,
+	} {
+		if v, ok := os.LookupEnv(env); ok {
+			*field = strings.TrimSpace(v)
+		}
+	}
+
+	// a token in the environment replaces consumer credentials from the
+	// file and the other way around, setting both there is still an error
+	envToken := strings.TrimSpace(os.Getenv("DISCOGS_TOKEN")) != ""
+	envConsumer := strings.TrimSpace(os.Getenv("DISCOGS_CONSUMER_KEY")) != "" ||
+		strings.TrimSpace(os.Getenv("DISCOGS_CONSUMER_SECRET")) != ""
+
+	switch {
+	case envToken && !envConsumer:
+		cfg.ConsumerKey, cfg.ConsumerSecret = "", ""
+	case envConsumer && !envToken:
+		cfg.Token = ""
+	}
+
+	if v, ok := os.LookupEnv("DISCOGS_RATE_LIMIT"); ok {
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return fmt.Errorf("invalid DISCOGS_RATE_LIMIT: %w", err)
+		}
+		cfg.RateLimit = n
+	}
+
+	if v, ok := os.LookupEnv("DISCOGS_TIMEOUT"); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid DISCOGS_TIMEOUT: %w", err)
+		}
+		cfg.Timeout = d
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var configEnv = []string{
+	"DISCOGS_CONFIG", "DISCOGS_TOKEN", "DISCOGS_CONSUMER_KEY", "DISCOGS_CONSUMER_SECRET",
+	"DISCOGS_BASE_URL", "DISCOGS_USER_AGENT", "DISCOGS_RATE_LIMIT", "DISCOGS_CACHE_DIR",
+	"DISCOGS_TIMEOUT",
+}
+
+// setConfigEnv clears the DISCOGS_* variables for the test, setting the
+// ones in env.
+func setConfigEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+
+	for _, k := range configEnv {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+}
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "discogs.json")
+	err := os.WriteFile(path, []byte(contents), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigEnvOverFile(t *testing.T) {
+	path := writeConfig(t, `{
+		"token": "file-token",
+		"base_url": "https://proxy.example.com/discogs",
+		"user_agent": "file-agent",
+		"rate_limit": 30,
+		"timeout": "45s"
+	}`)
+
+	setConfigEnv(t, map[string]string{
+		"DISCOGS_USER_AGENT": " env-agent ",
+		"DISCOGS_RATE_LIMIT": "20",
+	})
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Config{
+		Token:     "file-token",
+		BaseURL:   "https://proxy.example.com/discogs",
+		UserAgent: "env-agent",
+		RateLimit: 20,
+		Timeout:   45 * time.Second,
+	}
+	if *cfg != want {
+		t.Errorf("got %+v, want %+v", *cfg, want)
+	}
+}
+
+func TestLoadConfigFromEnvPath(t *testing.T) {
+	path := writeConfig(t, `{"token": "file-token", "timeout": "1m30s"}`)
+	setConfigEnv(t, map[string]string{
+		"DISCOGS_CONFIG":  path,
+		"DISCOGS_TIMEOUT": "2m",
+	})
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Token != "file-token" || cfg.Timeout != 2*time.Minute {
+		t.Errorf("got %+v", *cfg)
+	}
+}
+
+func TestLoadConfigCredentialsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		want    Config
+		wantErr string
+	}{
+		{
+			name: "consumer credentials replace a file token",
+			file: `{"token": "file-token"}`,
+			env:  map[string]string{"DISCOGS_CONSUMER_KEY": "key", "DISCOGS_CONSUMER_SECRET": "secret"},
+			want: Config{ConsumerKey: "key", ConsumerSecret: "secret"},
+		},
+		{
+			name: "a token replaces file consumer credentials",
+			file: `{"consumer_key": "key", "consumer_secret": "secret"}`,
+			env:  map[string]string{"DISCOGS_TOKEN": "env-token"},
+			want: Config{Token: "env-token"},
+		},
+		{
+			name: "a token replaces a file token",
+			file: `{"token": "file-token"}`,
+			env:  map[string]string{"DISCOGS_TOKEN": "env-token"},
+			want: Config{Token: "env-token"},
+		},
+		{
+			name:    "only a consumer key in the environment",
+			file:    `{"token": "file-token"}`,
+			env:     map[string]string{"DISCOGS_CONSUMER_KEY": "key"},
+			wantErr: "consumer key and secret must be set together",
+		},
+		{
+			name:    "both kinds in the environment",
+			file:    `{}`,
+			env:     map[string]string{"DISCOGS_TOKEN": "t", "DISCOGS_CONSUMER_KEY": "key", "DISCOGS_CONSUMER_SECRET": "secret"},
+			wantErr: "not both",
+		},
+		{
+			name:    "both kinds in the file",
+			file:    `{"token": "t", "consumer_key": "key", "consumer_secret": "secret"}`,
+			wantErr: "not both",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.file)
+			setConfigEnv(t, tt.env)
+
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *cfg != tt.want {
+				t.Errorf("got %+v, want %+v", *cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		wantErr []string
+	}{
+		{name: "bad timeout in the file", file: `{"timeout": "soon"}`, wantErr: []string{"invalid timeout"}},
+		{name: "numeric timeout in the file", file: `{"timeout": 30}`, wantErr: []string{"error parsing config file"}},
+		{name: "bad json", file: `{`, wantErr: []string{"error parsing config file"}},
+		{name: "bad DISCOGS_TIMEOUT", file: `{}`, env: map[string]string{"DISCOGS_TIMEOUT": "30"}, wantErr: []string{"invalid DISCOGS_TIMEOUT"}},
+		{name: "bad DISCOGS_RATE_LIMIT", file: `{}`, env: map[string]string{"DISCOGS_RATE_LIMIT": "fast"}, wantErr: []string{"invalid DISCOGS_RATE_LIMIT"}},
+		{name: "relative base url", file: `{"base_url": "api.discogs.com"}`, wantErr: []string{"must be an absolute url"}},
+		{name: "negative rate limit", file: `{"rate_limit": -1}`, wantErr: []string{"rate limit must not be negative"}},
+		{name: "negative timeout", file: `{"timeout": "-1s"}`, wantErr: []string{"timeout must not be negative"}},
+		{
+			name:    "every problem is reported",
+			file:    `{"consumer_secret": "secret", "rate_limit": -5, "base_url": "/v2"}`,
+			wantErr: []string{"invalid config", "set together", "absolute url", "rate limit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.file)
+			setConfigEnv(t, tt.env)
+
+			_, err := LoadConfig(path)
+			if err == nil {
+				t.Fatal("LoadConfig didn't fail")
+			}
+
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q doesn't mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	setConfigEnv(t, nil)
+
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil || !strings.Contains(err.Error(), "error reading config file") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	var auth, agent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, agent = r.Header.Get("Authorization"), r.Header.Get("User-Agent")
+		w.Write([]byte(`{"id":1,"username":"bob"}`))
+	}))
+	defer srv.Close()
+
+	setConfigEnv(t, map[string]string{
+		"DISCOGS_TOKEN":      "env-token",
+		"DISCOGS_BASE_URL":   srv.URL,
+		"DISCOGS_USER_AGENT": "env-agent",
+		"DISCOGS_RATE_LIMIT": "6000",
+		"DISCOGS_CACHE_DIR":  t.TempDir(),
+		"DISCOGS_TIMEOUT":    "5s",
+	})
+
+	c, err := NewFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.timeout != 5*time.Second || c.cache == nil {
+		t.Errorf("timeout %s, cache %v", c.timeout, c.cache)
+	}
+
+	id, err := c.Validate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id.Username != "bob" || auth != "Discogs token=env-token" || agent != "env-agent" {
+		t.Errorf("got %+v, authorization %q, user agent %q", id, auth, agent)
+	}
+
+	setConfigEnv(t, map[string]string{"DISCOGS_BASE_URL": "not a url"})
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("NewFromEnv with a bad base url didn't fail")
+	}
+}
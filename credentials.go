@@ -0,0 +1,226 @@
+package discogs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+	"github.com/dkaman/discogs-golang/oauth"
+)
+
+// Credentials are what a request is authenticated with. a client has
+// the ones it was built with, WithToken and the like, which calls can
+// override with WithCredentials or WithRequestCredentials to act for
+// someone else. each set of credentials gets a rate limiter of its own,
+// like discogs counts them, while the http client, cache and the rest
+// stay shared.
+type Credentials struct {
+	auth authenticator
+}
+
+// NewTokenCredentials authenticates as the user owning a personal access
+// token.
+func NewTokenCredentials(token string) *Credentials {
+	return &Credentials{auth: tokenAuth(token)}
+}
+
+// NewConsumerCredentials authenticates as an application only, see
+// WithConsumerCredentials.
+func NewConsumerCredentials(key string, secret string) *Credentials {
+	return &Credentials{auth: consumerAuth{key: key, secret: secret}}
+}
+
+// NewOAuthCredentials authenticates as the user that granted token.
+func NewOAuthCredentials(config *oauth.Config, token *oauth.Token) *Credentials {
+	return &Credentials{auth: oauthAuth{config: config, token: token}}
+}
+
+// UserAuth reports whether the credentials act on behalf of a discogs
+// user, which the collection and identity endpoints need.
+func (cr *Credentials) UserAuth() bool {
+	return cr.auth.userAuth()
+}
+
+func (cr *Credentials) validate() error {
+	if cr == nil || cr.auth == nil {
+		return errors.New("empty credentials")
+	}
+
+	switch a := cr.auth.(type) {
+	case tokenAuth:
+		if a == "" {
+			return errors.New("token is required")
+		}
+	case consumerAuth:
+		if a.key == "" || a.secret == "" {
+			return errors.New("consumer key and secret are required")
+		}
+	case oauthAuth:
+		if a.config == nil || a.token == nil {
+			return errors.New("oauth config and token are required")
+		}
+	}
+
+	return nil
+}
+
+// the map key for the credentials' limiter, hashed so tokens don't sit
+// around in memory any more than they have to
+func (cr *Credentials) key() string {
+	sum := sha256.Sum256([]byte(cr.auth.identity()))
+	return hex.EncodeToString(sum[:])
+}
+
+type credentialsKey struct{}
+
+// WithCredentials returns a context that makes every call made with it
+// use creds instead of the client's own credentials.
+func WithCredentials(ctx context.Context, creds *Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, creds)
+}
+
+func credentialsFrom(ctx context.Context) *Credentials {
+	creds, _ := ctx.Value(credentialsKey{}).(*Credentials)
+	return creds
+}
+
+// WithRequestCredentials authenticates one request with creds instead of
+// the client's own credentials, or the ones in the context.
+func WithRequestCredentials(creds *Credentials) options.Option[http.Request] {
+	return func(req *http.Request) error {
+		err := creds.validate()
+		if err != nil {
+			return err
+		}
+
+		updateRequestConfig(req, func(cfg *requestConfig) {
+			cfg.creds = creds
+		})
+		return nil
+	}
+}
+
+// WithCredentialRateLimiter sets how the limiter for credentials other
+// than the client's own is made. by default each gets a limiter like the
+// one the client starts with when authenticated. newLimiter is called again
+// for credentials that come back after going unused for ten minutes.
+func WithCredentialRateLimiter(newLimiter func(*Credentials) RateLimiter) options.Option[Client] {
+	return func(c *Client) error {
+		if newLimiter == nil {
+			return errors.New("nil credential rate limiter")
+		}
+		c.newCredentialLimiter = newLimiter
+		return nil
+	}
+}
+
+// Credentials returns the credentials the client was built with, nil if
+// it is unauthenticated.
+func (c *Client) Credentials() *Credentials {
+	if c.auth == nil {
+		return nil
+	}
+	return &Credentials{auth: c.auth}
+}
+
+// authFor returns what a call made with ctx authenticates with, the
+// credentials in ctx or else the client's own.
+func (c *Client) authFor(ctx context.Context) authenticator {
+	if creds := credentialsFrom(ctx); creds != nil {
+		return creds.auth
+	}
+	return c.auth
+}
+
+// defaultCredentials reports whether a call made with ctx uses the
+// credentials the client was built with.
+func (c *Client) defaultCredentials(ctx context.Context) bool {
+	return c.ownCredentials(credentialsFrom(ctx))
+}
+
+func (c *Client) ownCredentials(creds *Credentials) bool {
+	return creds == nil || c.auth != nil && creds.auth.identity() == c.auth.identity()
+}
+
+// credentials unused for this long lose their scheduler, so a client acting
+// for many users one after another doesn't hold on to all of them. by then
+// discogs has long forgotten the calls made with them, so a new limiter
+// starts out no worse off than the old one.
+const credentialIdleTimeout = 10 * time.Minute
+
+// credentialLimiters holds the schedulers for credentials other than the
+// client's own, made the first time they are used and dropped once idle for
+// credentialIdleTimeout.
+type credentialLimiters struct {
+	mu         sync.Mutex
+	schedulers map[string]*credentialScheduler
+	pruned     time.Time
+}
+
+type credentialScheduler struct {
+	*scheduler
+	lastUsed time.Time
+}
+
+// schedulerFor returns the scheduler req waits on, the client's own unless
+// req was built with other credentials.
+func (c *Client) schedulerFor(req *http.Request) (*scheduler, error) {
+	creds := requestConfigFrom(req.Context()).creds
+	if c.ownCredentials(creds) {
+		return c.scheduler, nil
+	}
+
+	key := creds.key()
+	now := time.Now()
+
+	c.limiters.mu.Lock()
+	defer c.limiters.mu.Unlock()
+
+	// sweeping once a window is plenty for a ten minute timeout and keeps
+	// lookups from walking the whole map
+	if now.Sub(c.limiters.pruned) >= rateLimitWindow {
+		c.limiters.prune(now)
+	}
+
+	if cs, ok := c.limiters.schedulers[key]; ok {
+		cs.lastUsed = now
+		return cs.scheduler, nil
+	}
+
+	var limiter RateLimiter
+	if c.newCredentialLimiter != nil {
+		limiter = c.newCredentialLimiter(creds)
+	} else {
+		limiter = newAdaptiveLimiter(55)
+	}
+
+	s, err := newScheduler(limiter, c.quotas, c.maxQueueWait)
+	if err != nil {
+		return nil, fmt.Errorf("error creating rate limiter for credentials: %w", err)
+	}
+
+	if c.limiters.schedulers == nil {
+		c.limiters.schedulers = make(map[string]*credentialScheduler)
+	}
+	c.limiters.schedulers[key] = &credentialScheduler{scheduler: s, lastUsed: now}
+
+	return s, nil
+}
+
+// prune drops the schedulers idle for longer than credentialIdleTimeout,
+// keeping any with calls still waiting on them.
+func (l *credentialLimiters) prune(now time.Time) {
+	l.pruned = now
+
+	for key, cs := range l.schedulers {
+		if now.Sub(cs.lastUsed) >= credentialIdleTimeout && cs.Len() == 0 {
+			delete(l.schedulers, key)
+		}
+	}
+}
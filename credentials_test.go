@@ -0,0 +1,75 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCredentialSchedulersEvictedWhenIdle(t *testing.T) {
+	made := 0
+	c, err := New(
+		WithToken("own"),
+		WithCredentialRateLimiter(func(*Credentials) RateLimiter {
+			made++
+			return newAdaptiveLimiter(60)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schedulerFor := func(creds *Credentials) *scheduler {
+		t.Helper()
+
+		req, err := c.NewRequest(context.Background(), http.MethodGet, "oauth/identity", nil, WithRequestCredentials(creds))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := c.schedulerFor(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	idle := NewTokenCredentials("idle")
+	busy := NewTokenCredentials("busy")
+	recent := NewTokenCredentials("recent")
+
+	first := schedulerFor(idle)
+	if again := schedulerFor(idle); again != first {
+		t.Error("same credentials got a second scheduler")
+	}
+
+	schedulerFor(busy).queue = []*waiter{{}}
+	schedulerFor(recent)
+
+	if made != 3 {
+		t.Fatalf("made %d limiters, want 3", made)
+	}
+
+	now := time.Now()
+	c.limiters.mu.Lock()
+	for _, creds := range []*Credentials{idle, busy} {
+		c.limiters.schedulers[creds.key()].lastUsed = now.Add(-credentialIdleTimeout)
+	}
+	c.limiters.prune(now)
+	n := len(c.limiters.schedulers)
+	_, busyKept := c.limiters.schedulers[busy.key()]
+	c.limiters.mu.Unlock()
+
+	if n != 2 || !busyKept {
+		t.Errorf("%d schedulers left after pruning, want the busy and recent ones", n)
+	}
+
+	if status := c.RateStatusFor(idle); status != (RateStatus{}) {
+		t.Errorf("evicted credentials report %+v", status)
+	}
+
+	if schedulerFor(idle) == first || made != 4 {
+		t.Error("evicted credentials didn't get a new scheduler")
+	}
+}
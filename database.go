@@ -2,7 +2,6 @@ package discogs
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 )
@@ -130,25 +129,20 @@ type GetReleaseResonse struct {
 }
 
 func (s *DatabaseService) GetRelease(ctx context.Context, id int) (release *Release, err error) {
-	u := fmt.Sprintf("/releases/%d", id)
+	ctx, end := s.client.startOperation(ctx, "DatabaseService.GetRelease",
+		Attribute{Key: "discogs.release_id", Value: id},
+	)
+	defer end(&err)
 
-	req, err := s.client.NewRequest(http.MethodGet, u, nil)
-	if err != nil {
-		return
-	}
+	u := fmt.Sprintf("releases/%d", id)
 
-	resp, err := s.client.Do(ctx, req)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non 200 from server, got %d", resp.StatusCode)
-	}
-
 	var out Release
-
-	err = json.NewDecoder(resp.Body).Decode(&out)
+	_, err = s.client.Do(ctx, req, &out)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,128 @@
+package discogstest
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+type pagination struct {
+	Page    int            `json:"page"`
+	Pages   int            `json:"pages"`
+	PerPage int            `json:"per_page"`
+	Items   int            `json:"items"`
+	URLs    paginationURLs `json:"urls"`
+}
+
+type paginationURLs struct {
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// writePage answers with the page of items asked for through the page and
+// per_page query parameters, wrapped the way discogs does it with the
+// items under key next to a pagination object.
+func writePage[T any](w http.ResponseWriter, r *http.Request, key string, items []T) {
+	q := r.URL.Query()
+
+	perPage := defaultPerPage
+	if v, err := strconv.Atoi(q.Get("per_page")); err == nil && v > 0 {
+		perPage = min(v, maxPerPage)
+	}
+
+	pages := max((len(items)+perPage-1)/perPage, 1)
+
+	page := 1
+	if v, err := strconv.Atoi(q.Get("page")); err == nil && v > 0 {
+		page = v
+	}
+
+	if page > pages {
+		writeError(w, http.StatusNotFound, "That page does not exist.")
+		return
+	}
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	pageURL := func(n int) string {
+		u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+		v := r.URL.Query()
+		v.Set("page", strconv.Itoa(n))
+		v.Set("per_page", strconv.Itoa(perPage))
+		u.RawQuery = v.Encode()
+		return u.String()
+	}
+
+	p := pagination{
+		Page:    page,
+		Pages:   pages,
+		PerPage: perPage,
+		Items:   len(items),
+	}
+
+	if page > 1 {
+		p.URLs.First = pageURL(1)
+		p.URLs.Prev = pageURL(page - 1)
+	}
+
+	if page < pages {
+		p.URLs.Next = pageURL(page + 1)
+		p.URLs.Last = pageURL(pages)
+	}
+
+	pageItems := items[start:end]
+	if pageItems == nil {
+		pageItems = []T{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pagination": p,
+		key:          pageItems,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+// readJSON decodes the request body into v, an empty body leaves v alone.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Request body is not valid JSON.")
+		return false
+	}
+	return true
+}
+
+func pathInt(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	n, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "The requested resource was not found.")
+		return 0, false
+	}
+	return n, true
+}
+
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,741 @@
+package discogstest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	discogs "github.com/dkaman/discogs-golang"
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+const (
+	defaultRateLimit = 60
+	defaultPerPage   = 50
+	maxPerPage       = 100
+	rateLimitWindow  = 60 * time.Second
+)
+
+// Server is an in-memory stand in for api.discogs.com covering identity,
+// releases and the collection endpoints. it keeps real state, so folders
+// created through it can be listed, renamed and deleted afterwards, and
+// answers with the same status codes, pagination and rate limit headers
+// discogs does.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	rateLimit int
+	requests  []time.Time
+	users     map[string]*user
+	tokens    map[string]*user
+	releases  map[int]discogs.Release
+	nextUser  int
+}
+
+type user struct {
+	identity     discogs.Identity
+	folders      map[int]*discogs.Folder
+	instances    map[int]*instance
+	fields       []discogs.Field
+	nextFolder   int
+	nextInstance int
+}
+
+type instance struct {
+	id        int
+	releaseID int
+	folderID  int
+	rating    int
+	dateAdded time.Time
+	notes     map[int]string
+}
+
+func NewServer(opts ...options.Option[Server]) (*Server, error) {
+	s := &Server{
+		rateLimit: defaultRateLimit,
+		users:     make(map[string]*user),
+		tokens:    make(map[string]*user),
+		releases:  make(map[int]discogs.Release),
+	}
+
+	err := options.Apply(s, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /oauth/identity", s.handleIdentity)
+	mux.HandleFunc("GET /releases/{release_id}", s.handleGetRelease)
+	mux.HandleFunc("GET /users/{username}/collection/folders", s.handleListFolders)
+	mux.HandleFunc("POST /users/{username}/collection/folders", s.handleCreateFolder)
+	mux.HandleFunc("GET /users/{username}/collection/folders/{folder_id}", s.handleGetFolder)
+	mux.HandleFunc("POST /users/{username}/collection/folders/{folder_id}", s.handleEditFolder)
+	mux.HandleFunc("DELETE /users/{username}/collection/folders/{folder_id}", s.handleDeleteFolder)
+	mux.HandleFunc("GET /users/{username}/collection/folders/{folder_id}/releases", s.handleFolderReleases)
+	mux.HandleFunc("POST /users/{username}/collection/folders/{folder_id}/releases/{release_id}", s.handleAddRelease)
+	mux.HandleFunc("POST /users/{username}/collection/folders/{folder_id}/releases/{release_id}/instances/{instance_id}", s.handleEditInstance)
+	mux.HandleFunc("DELETE /users/{username}/collection/folders/{folder_id}/releases/{release_id}/instances/{instance_id}", s.handleDeleteInstance)
+	mux.HandleFunc("POST /users/{username}/collection/folders/{folder_id}/releases/{release_id}/instances/{instance_id}/fields/{field_id}", s.handleEditField)
+	mux.HandleFunc("GET /users/{username}/collection/releases/{release_id}", s.handleReleaseInstances)
+	mux.HandleFunc("GET /users/{username}/collection/fields", s.handleListFields)
+	mux.HandleFunc("GET /users/{username}/collection/value", s.handleValue)
+
+	s.Server = httptest.NewServer(s.rateLimited(mux))
+
+	return s, nil
+}
+
+// WithRateLimit sets how many requests the server allows in a 60 second
+// window before answering 429, 60 by default like an authenticated client.
+func WithRateLimit(perMinute int) options.Option[Server] {
+	return func(s *Server) error {
+		if perMinute <= 0 {
+			return fmt.Errorf("rate limit must be positive, got %d", perMinute)
+		}
+		s.rateLimit = perMinute
+		return nil
+	}
+}
+
+// HTTPClient returns a client that sends every request to the fake server
+// whatever host it was built for, pass it to discogs.WithHTTPClient.
+func (s *Server) HTTPClient() *http.Client {
+	target, _ := url.Parse(s.URL)
+	inner := s.Server.Client().Transport
+
+	return &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.Host = target.Host
+			return inner.RoundTrip(req)
+		}),
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// AddUser registers a user that authenticates with token, starting out with
+// the default All and Uncategorized folders and the default custom fields.
+func (s *Server) AddUser(username string, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextUser++
+	u := &user{
+		identity: discogs.Identity{
+			ID:           s.nextUser,
+			Username:     username,
+			ResourceURL:  s.URL + "/users/" + username,
+			ConsumerName: "discogstest",
+		},
+		folders: map[int]*discogs.Folder{
+			0: {ID: 0, Name: "All"},
+			1: {ID: 1, Name: "Uncategorized"},
+		},
+		instances: make(map[int]*instance),
+		fields: []discogs.Field{
+			{ID: 1, Name: "Media Condition", Type: "dropdown", Position: 1, Public: true, Options: []string{
+				"Mint (M)", "Near Mint (NM or M-)", "Very Good Plus (VG+)", "Very Good (VG)",
+				"Good Plus (G+)", "Good (G)", "Fair (F)", "Poor (P)",
+			}},
+			{ID: 2, Name: "Sleeve Condition", Type: "dropdown", Position: 2, Public: true, Options: []string{
+				"Generic", "No Cover", "Mint (M)", "Near Mint (NM or M-)", "Very Good Plus (VG+)",
+				"Very Good (VG)", "Good Plus (G+)", "Good (G)", "Fair (F)", "Poor (P)",
+			}},
+			{ID: 3, Name: "Notes", Type: "textarea", Position: 3, Public: false, Lines: 3},
+		},
+		nextFolder:   2,
+		nextInstance: 1,
+	}
+
+	for _, f := range u.folders {
+		f.ResourceURL = s.folderURL(username, f.ID)
+	}
+
+	s.users[username] = u
+	s.tokens[token] = u
+}
+
+// AddRelease makes a release available through /releases/{id} and for
+// adding to collections.
+func (s *Server) AddRelease(release discogs.Release) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if release.ResourceURL == "" {
+		release.ResourceURL = fmt.Sprintf("%s/releases/%d", s.URL, release.ID)
+	}
+
+	s.releases[release.ID] = release
+}
+
+// rateLimited tracks requests in a 60 second moving window like discogs,
+// setting the x-discogs-ratelimit headers on every response and answering
+// 429 once the window is full.
+func (s *Server) rateLimited(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		now := time.Now()
+		cutoff := now.Add(-rateLimitWindow)
+
+		i := sort.Search(len(s.requests), func(i int) bool { return s.requests[i].After(cutoff) })
+		s.requests = s.requests[i:]
+
+		limited := len(s.requests) >= s.rateLimit
+		if !limited {
+			s.requests = append(s.requests, now)
+		}
+		used := len(s.requests)
+		s.mu.Unlock()
+
+		w.Header().Set("X-Discogs-Ratelimit", strconv.Itoa(s.rateLimit))
+		w.Header().Set("X-Discogs-Ratelimit-Used", strconv.Itoa(used))
+		w.Header().Set("X-Discogs-Ratelimit-Remaining", strconv.Itoa(s.rateLimit-used))
+
+		if limited {
+			writeError(w, http.StatusTooManyRequests, "You are making requests too quickly.")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleIdentity(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.authenticated(r)
+	if u == nil {
+		writeError(w, http.StatusUnauthorized, "You must authenticate to access this resource.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u.identity)
+}
+
+func (s *Server) handleGetRelease(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := pathInt(w, r, "release_id")
+	if !ok {
+		return
+	}
+
+	release, ok := s.releases[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Release not found.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, release)
+}
+
+func (s *Server) handleListFolders(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, owner, ok := s.collectionOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	folders := []discogs.Folder{}
+	for _, id := range sortedKeys(u.folders) {
+		// everyone else only gets to see the All folder
+		if !owner && id != 0 {
+			continue
+		}
+		folders = append(folders, s.folderWithCount(u, id))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"folders": folders})
+}
+
+func (s *Server) handleCreateFolder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, _, ok := s.collectionOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	if body.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Folder name is required.")
+		return
+	}
+
+	id := u.nextFolder
+	u.nextFolder++
+	u.folders[id] = &discogs.Folder{
+		ID:          id,
+		Name:        body.Name,
+		ResourceURL: s.folderURL(u.identity.Username, id),
+	}
+
+	writeJSON(w, http.StatusCreated, s.folderWithCount(u, id))
+}
+
+func (s *Server) handleGetFolder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, owner, ok := s.collectionOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	id, ok := s.folder(w, r, u)
+	if !ok {
+		return
+	}
+
+	if !owner && id != 0 {
+		writeError(w, http.StatusForbidden, "You don't have permission to access this resource.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.folderWithCount(u, id))
+}
+
+func (s *Server) handleEditFolder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, _, ok := s.collectionOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	id, ok := s.folder(w, r, u)
+	if !ok {
+		return
+	}
+
+	if id == 0 || id == 1 {
+		writeError(w, http.StatusForbidden, "Cannot rename this folder.")
+		return
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	if body.Name != "" {
+		u.folders[id].Name = body.Name
+	}
+
+	writeJSON(w, http.StatusOK, s.folderWithCount(u, id))
+}
+
+func (s *Server) handleDeleteFolder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, _, ok := s.collectionOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	id, ok := s.folder(w, r, u)
+	if !ok {
+		return
+	}
+
+	if id == 0 || id == 1 {
+		writeError(w, http.StatusForbidden, "Cannot delete this folder.")
+		return
+	}
+
+	if s.folderWithCount(u, id).Count > 0 {
+		writeError(w, http.StatusForbidden, "Folder must be empty to be deleted.")
+		return
+	}
+
+	delete(u.folders, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleFolderReleases(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, owner, ok := s.collectionOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	id, ok := s.folder(w, r, u)
+	if !ok {
+		return
+	}
+
+	if !owner && id != 0 {
+		writeError(w, http.StatusForbidden, "You don't have permission to access this resource.")
+		return
+	}
+
+	var releases []discogs.ReleaseInstance
+	for _, iid := range sortedKeys(u.instances) {
+		in := u.instances[iid]
+		if id == 0 || in.folderID == id {
+			releases = append(releases, s.releaseInstance(in))
+		}
+	}
+
+	writePage(w, r, "releases", releases)
+}
+
+func (s *Server) handleAddRelease(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, _, ok := s.collectionOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	folderID, ok := s.folder(w, r, u)
+	if !ok {
+		return
+	}
+
+	if folderID == 0 {
+		writeError(w, http.StatusForbidden, "Cannot add releases to the All folder.")
+		return
+	}
+
+	releaseID, ok := pathInt(w, r, "release_id")
+	if !ok {
+		return
+	}
+
+	if _, ok := s.releases[releaseID]; !ok {
+		writeError(w, http.StatusNotFound, "Release not found.")
+		return
+	}
+
+	in := &instance{
+		id:        u.nextInstance,
+		releaseID: releaseID,
+		folderID:  folderID,
+		dateAdded: time.Now(),
+		notes:     make(map[int]string),
+	}
+	u.nextInstance++
+	u.instances[in.id] = in
+
+	writeJSON(w, http.StatusCreated, discogs.Instance{
+		ID:          in.id,
+		ResourceURL: s.instanceURL(u.identity.Username, in),
+	})
+}
+
+func (s *Server) handleEditInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, in, ok := s.instance(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Rating   *int `json:"rating"`
+		FolderID *int `json:"folder_id"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	if body.Rating != nil {
+		if *body.Rating < 0 || *body.Rating > 5 {
+			writeError(w, http.StatusUnprocessableEntity, "Rating must be between 0 and 5.")
+			return
+		}
+		in.rating = *body.Rating
+	}
+
+	if body.FolderID != nil {
+		if _, ok := u.folders[*body.FolderID]; !ok || *body.FolderID == 0 {
+			writeError(w, http.StatusNotFound, "Folder not found.")
+			return
+		}
+		in.folderID = *body.FolderID
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, in, ok := s.instance(w, r)
+	if !ok {
+		return
+	}
+
+	delete(u.instances, in.id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleEditField(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, in, ok := s.instance(w, r)
+	if !ok {
+		return
+	}
+
+	fieldID, ok := pathInt(w, r, "field_id")
+	if !ok {
+		return
+	}
+
+	found := false
+	for _, f := range u.fields {
+		found = found || f.ID == fieldID
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "Field not found.")
+		return
+	}
+
+	// discogs takes the value as a query parameter or in the body
+	value := r.URL.Query().Get("value")
+	if !r.URL.Query().Has("value") {
+		var body struct {
+			Value string `json:"value"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		value = body.Value
+	}
+
+	in.notes[fieldID] = value
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleReleaseInstances(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, _, ok := s.collectionOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	releaseID, ok := pathInt(w, r, "release_id")
+	if !ok {
+		return
+	}
+
+	if _, ok := s.releases[releaseID]; !ok {
+		writeError(w, http.StatusNotFound, "Release not found.")
+		return
+	}
+
+	var releases []discogs.ReleaseInstance
+	for _, iid := range sortedKeys(u.instances) {
+		if in := u.instances[iid]; in.releaseID == releaseID {
+			releases = append(releases, s.releaseInstance(in))
+		}
+	}
+
+	writePage(w, r, "releases", releases)
+}
+
+func (s *Server) handleListFields(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, owner, ok := s.collectionOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	fields := []discogs.Field{}
+	for _, f := range u.fields {
+		if owner || f.Public {
+			fields = append(fields, f)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"fields": fields})
+}
+
+func (s *Server) handleValue(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, _, ok := s.collectionOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	var total float64
+	for _, in := range u.instances {
+		total += s.releases[in.releaseID].LowestPrice
+	}
+
+	writeJSON(w, http.StatusOK, discogs.Value{
+		Minimum: fmt.Sprintf("$%.2f", total*0.5),
+		Median:  fmt.Sprintf("$%.2f", total),
+		Maximum: fmt.Sprintf("$%.2f", total*1.5),
+	})
+}
+
+func (s *Server) authenticated(r *http.Request) *user {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Discogs token=")
+	if !ok {
+		return nil
+	}
+	return s.tokens[token]
+}
+
+// collectionOwner looks up the user in the path and whether the request is
+// authenticated as them. with ownerOnly set anyone else gets a 401 or 403
+// and ok comes back false.
+func (s *Server) collectionOwner(w http.ResponseWriter, r *http.Request, ownerOnly bool) (u *user, owner bool, ok bool) {
+	u, found := s.users[r.PathValue("username")]
+	if !found {
+		writeError(w, http.StatusNotFound, "User does not exist or may have been deleted.")
+		return nil, false, false
+	}
+
+	caller := s.authenticated(r)
+	owner = caller == u
+
+	if ownerOnly && caller == nil {
+		writeError(w, http.StatusUnauthorized, "You must authenticate to access this resource.")
+		return nil, false, false
+	}
+
+	if ownerOnly && !owner {
+		writeError(w, http.StatusForbidden, "You don't have permission to access this resource.")
+		return nil, false, false
+	}
+
+	return u, owner, true
+}
+
+func (s *Server) folder(w http.ResponseWriter, r *http.Request, u *user) (int, bool) {
+	id, ok := pathInt(w, r, "folder_id")
+	if !ok {
+		return 0, false
+	}
+
+	if _, found := u.folders[id]; !found {
+		writeError(w, http.StatusNotFound, "Folder not found.")
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (s *Server) instance(w http.ResponseWriter, r *http.Request) (*user, *instance, bool) {
+	u, _, ok := s.collectionOwner(w, r, true)
+	if !ok {
+		return nil, nil, false
+	}
+
+	folderID, ok := s.folder(w, r, u)
+	if !ok {
+		return nil, nil, false
+	}
+
+	releaseID, ok := pathInt(w, r, "release_id")
+	if !ok {
+		return nil, nil, false
+	}
+
+	instanceID, ok := pathInt(w, r, "instance_id")
+	if !ok {
+		return nil, nil, false
+	}
+
+	in, found := u.instances[instanceID]
+	if !found || in.releaseID != releaseID || (folderID != 0 && in.folderID != folderID) {
+		writeError(w, http.StatusNotFound, "Instance not found.")
+		return nil, nil, false
+	}
+
+	return u, in, true
+}
+
+func (s *Server) folderWithCount(u *user, id int) discogs.Folder {
+	f := *u.folders[id]
+	f.Count = 0
+	for _, in := range u.instances {
+		if id == 0 || in.folderID == id {
+			f.Count++
+		}
+	}
+	return f
+}
+
+func (s *Server) releaseInstance(in *instance) discogs.ReleaseInstance {
+	release := s.releases[in.releaseID]
+
+	ri := discogs.ReleaseInstance{
+		ID:         in.releaseID,
+		InstanceID: in.id,
+		DateAdded:  in.dateAdded.Format(time.RFC3339),
+		FolderID:   in.folderID,
+		Rating:     in.rating,
+	}
+
+	ri.BasicInfo.ID = release.ID
+	ri.BasicInfo.ResourceURL = release.ResourceURL
+	ri.BasicInfo.MasterID = release.MasterID
+	ri.BasicInfo.MasterURL = release.MasterURL
+	ri.BasicInfo.Thumb = release.Thumb
+	ri.BasicInfo.Title = release.Title
+	ri.BasicInfo.Year = release.Year
+	ri.BasicInfo.Genres = release.Genres
+	ri.BasicInfo.Styles = release.Styles
+	ri.BasicInfo.Artists = release.Artists
+	ri.BasicInfo.Labels = release.Labels
+	ri.BasicInfo.Formats = release.Formats
+
+	return ri
+}
+
+func (s *Server) folderURL(username string, id int) string {
+	return fmt.Sprintf("%s/users/%s/collection/folders/%d", s.URL, username, id)
+}
+
+func (s *Server) instanceURL(username string, in *instance) string {
+	return fmt.Sprintf("%s/releases/%d/instances/%d", s.folderURL(username, in.folderID), in.releaseID, in.id)
+}
@@ -0,0 +1,79 @@
+package discogstest
+
+import (
+	"context"
+	"testing"
+
+	discogs "github.com/dkaman/discogs-golang"
+)
+
+func newTestClient(t *testing.T) (*Server, *discogs.Client) {
+	t.Helper()
+
+	s, err := NewServer(WithRateLimit(6000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	s.AddUser("bob", "secret")
+	s.AddRelease(discogs.Release{ID: 1, Title: "Kind of Blue"})
+
+	c, err := discogs.New(
+		discogs.WithToken("secret"),
+		discogs.WithHTTPClient(s.HTTPClient()),
+		discogs.WithRateLimiter(discogs.NewRateLimiter(6000)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, c
+}
+
+func TestRemoveReleaseFromFolder(t *testing.T) {
+	_, c := newTestClient(t)
+	ctx := context.Background()
+
+	in, err := c.Collection.AddReleaseToFolder(ctx, "bob", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Collection.RemoveReleaseFromFolder(ctx, "bob", 1, 1, in.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	releases, err := c.Collection.GetFolderByRelease(ctx, "bob", 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(releases) != 0 {
+		t.Errorf("release still in the collection after removing it: %+v", releases)
+	}
+}
+
+func TestEditCustomFields(t *testing.T) {
+	s, c := newTestClient(t)
+	ctx := context.Background()
+
+	in, err := c.Collection.AddReleaseToFolder(ctx, "bob", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Collection.EditCustomFields(ctx, "bob", 1, 1, in.ID, 3, "first pressing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.mu.Lock()
+	got := s.users["bob"].instances[in.ID].notes[3]
+	s.mu.Unlock()
+
+	if got != "first pressing" {
+		t.Errorf("field 3 = %q, want %q", got, "first pressing")
+	}
+}
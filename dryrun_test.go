@@ -0,0 +1,213 @@
+package discogs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// requestLog is a server that records the method and path of everything
+// that reaches it.
+type requestLog struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	seen []string
+}
+
+func newRequestLog(t *testing.T) *requestLog {
+	t.Helper()
+
+	l := &requestLog{}
+	l.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.mu.Lock()
+		l.seen = append(l.seen, r.Method+" "+r.URL.Path)
+		l.mu.Unlock()
+
+		w.Write([]byte(`{"folders":[{"id":0,"name":"All"}]}`))
+	}))
+	t.Cleanup(l.Close)
+
+	return l
+}
+
+func (l *requestLog) requests() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return slices.Clone(l.seen)
+}
+
+func newDryRunClient(t *testing.T, srv *requestLog, dryRun bool) *Client {
+	t.Helper()
+
+	c, err := New(
+		WithToken("secret"),
+		WithBaseURL(srv.URL),
+		WithRateLimiter(NewRateLimiter(6000)),
+		WithDryRun(dryRun),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestDryRunRecordsMutations(t *testing.T) {
+	srv := newRequestLog(t)
+	c := newDryRunClient(t, srv, true)
+	ctx := context.Background()
+
+	folder, err := c.Collection.CreateFolder(ctx, "bob", "Jazz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if folder.Name != "Jazz" {
+		t.Errorf("CreateFolder decoded %+v from the echoed body", folder)
+	}
+
+	req, err := c.NewRequest(ctx, http.MethodPut, "users/bob/wants/1?token=secret", map[string]int{"rating": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(ctx, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.DryRun || resp.StatusCode != http.StatusOK {
+		t.Errorf("PUT got status %d, dry run %v", resp.StatusCode, resp.DryRun)
+	}
+
+	err = c.Collection.DeleteFolder(ctx, "bob", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seen := srv.requests(); len(seen) != 0 {
+		t.Errorf("dry run sent %v", seen)
+	}
+
+	ops := c.PlannedOperations()
+	want := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/users/bob/collection/folders", `{"username":"bob","name":"Jazz"}`},
+		{http.MethodPut, "/users/bob/wants/1?token=REDACTED", `{"rating":5}`},
+		{http.MethodDelete, "/users/bob/collection/folders/3", ""},
+	}
+
+	if len(ops) != len(want) {
+		t.Fatalf("planned %+v, want %d operations", ops, len(want))
+	}
+
+	for i, w := range want {
+		op := ops[i]
+		if op.Method != w.method || op.Path != w.path || string(op.Body) != w.body {
+			t.Errorf("operation %d is %s %s %q, want %s %s %q", i, op.Method, op.Path, op.Body, w.method, w.path, w.body)
+		}
+		if op.Body != nil && !json.Valid(op.Body) {
+			t.Errorf("operation %d body isn't json: %s", i, op.Body)
+		}
+	}
+}
+
+func TestDryRunSendsGets(t *testing.T) {
+	srv := newRequestLog(t)
+	c := newDryRunClient(t, srv, true)
+
+	folders, err := c.Collection.ListFolders(context.Background(), "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(folders) != 1 || folders[0].Name != "All" {
+		t.Errorf("got folders %+v", folders)
+	}
+
+	if seen := srv.requests(); !slices.Equal(seen, []string{"GET /users/bob/collection/folders"}) {
+		t.Errorf("server saw %v", seen)
+	}
+
+	if ops := c.PlannedOperations(); len(ops) != 0 {
+		t.Errorf("GET was planned: %+v", ops)
+	}
+}
+
+func TestDryRunContextOverridesClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("off for one call", func(t *testing.T) {
+		srv := newRequestLog(t)
+		c := newDryRunClient(t, srv, true)
+
+		err := c.Collection.DeleteFolder(WithDryRunContext(ctx, false), "bob", 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Collection.DeleteFolder(ctx, "bob", 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seen := srv.requests(); !slices.Equal(seen, []string{"DELETE /users/bob/collection/folders/3"}) {
+			t.Errorf("server saw %v", seen)
+		}
+		if ops := c.PlannedOperations(); len(ops) != 1 || ops[0].Path != "/users/bob/collection/folders/4" {
+			t.Errorf("planned %+v", ops)
+		}
+	})
+
+	t.Run("on for one call", func(t *testing.T) {
+		srv := newRequestLog(t)
+		c := newDryRunClient(t, srv, false)
+
+		err := c.Collection.DeleteFolder(WithDryRunContext(ctx, true), "bob", 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.Collection.DeleteFolder(ctx, "bob", 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seen := srv.requests(); !slices.Equal(seen, []string{"DELETE /users/bob/collection/folders/4"}) {
+			t.Errorf("server saw %v", seen)
+		}
+		if ops := c.PlannedOperations(); len(ops) != 1 || ops[0].Path != "/users/bob/collection/folders/3" {
+			t.Errorf("planned %+v", ops)
+		}
+	})
+}
+
+func TestResetPlannedOperations(t *testing.T) {
+	srv := newRequestLog(t)
+	c := newDryRunClient(t, srv, true)
+	ctx := context.Background()
+
+	c.Collection.DeleteFolder(ctx, "bob", 3)
+
+	ops := c.PlannedOperations()
+	if len(ops) != 1 {
+		t.Fatalf("planned %+v", ops)
+	}
+
+	// the returned slice is a copy
+	ops[0].Method = "changed"
+	if c.PlannedOperations()[0].Method != http.MethodDelete {
+		t.Error("changing the returned operations changed the plan")
+	}
+
+	c.ResetPlannedOperations()
+	if ops := c.PlannedOperations(); len(ops) != 0 {
+		t.Errorf("planned %+v after a reset", ops)
+	}
+
+	c.Collection.DeleteFolder(ctx, "bob", 4)
+	if ops := c.PlannedOperations(); len(ops) != 1 || ops[0].Method != http.MethodDelete {
+		t.Errorf("planned %+v after the reset", ops)
+	}
+}
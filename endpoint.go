@@ -0,0 +1,116 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+	"github.com/dkaman/discogs-golang/internal/query"
+)
+
+// the helpers here let callers reach endpoints the services don't wrap yet
+// with their own types. path is resolved against the client base url like
+// the services do it, e.g. "users/someone/wants", and query is merged into
+// whatever query path already has. the response goes through Do, so errors
+// are *ErrorResponse values for non 2xx statuses and retries, caching,
+// coalescing and the rest apply as usual.
+
+// Get fetches path and decodes the response body into a T.
+func Get[T any](ctx context.Context, c *Client, path string, query url.Values, opts ...options.Option[http.Request]) (v *T, resp *Response, err error) {
+	ctx, end := c.startOperation(ctx, "Client.Get", Attribute{Key: "discogs.path", Value: path})
+	defer end(&err)
+
+	return doEndpoint[T](ctx, c, http.MethodGet, path, query, nil, opts...)
+}
+
+// Post sends body as json to path and decodes the response into a T. an
+// empty response body leaves the T at its zero value.
+func Post[T any](ctx context.Context, c *Client, path string, body any, opts ...options.Option[http.Request]) (v *T, resp *Response, err error) {
+	ctx, end := c.startOperation(ctx, "Client.Post", Attribute{Key: "discogs.path", Value: path})
+	defer end(&err)
+
+	return doEndpoint[T](ctx, c, http.MethodPost, path, nil, body, opts...)
+}
+
+// Put is Post with PUT, which discogs uses for adding to wantlists and
+// the like.
+func Put[T any](ctx context.Context, c *Client, path string, body any, opts ...options.Option[http.Request]) (v *T, resp *Response, err error) {
+	ctx, end := c.startOperation(ctx, "Client.Put", Attribute{Key: "discogs.path", Value: path})
+	defer end(&err)
+
+	return doEndpoint[T](ctx, c, http.MethodPut, path, nil, body, opts...)
+}
+
+// Delete sends a DELETE to path, discarding whatever comes back.
+func Delete(ctx context.Context, c *Client, path string, opts ...options.Option[http.Request]) (resp *Response, err error) {
+	ctx, end := c.startOperation(ctx, "Client.Delete", Attribute{Key: "discogs.path", Value: path})
+	defer end(&err)
+
+	req, err := newEndpointRequest(ctx, c, http.MethodDelete, path, nil, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(ctx, req, nil)
+}
+
+// QueryValues encodes a struct with `url:"name,omitempty"` field tags, a
+// ListOptions for instance, into the query Get and Paginate take.
+func QueryValues(v any) (url.Values, error) {
+	return query.Values(v)
+}
+
+// Paginate walks a paginated endpoint, decoding each page into a T and
+// handing it to fn. T is the whole page, so it has to name the field the
+// items come under, like
+//
+//	struct {
+//		Wants []Want `json:"wants"`
+//	}
+//
+// fn can return ErrPageDone to stop early without an error, any other
+// error stops the walk and is returned. unlike the list methods no
+// per_page is added, set it in query to go easy on the rate budget.
+func Paginate[T any](ctx context.Context, c *Client, path string, query url.Values, fn func(page *T) error) (err error) {
+	ctx, end := c.startOperation(ctx, "Client.Paginate", Attribute{Key: "discogs.path", Value: path})
+	defer end(&err)
+
+	return paginate(ctx, c, path, query, false, fn)
+}
+
+func doEndpoint[T any](ctx context.Context, c *Client, method, path string, query url.Values, body any, opts ...options.Option[http.Request]) (*T, *Response, error) {
+	req, err := newEndpointRequest(ctx, c, method, path, query, body, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var v T
+	resp, err := c.Do(ctx, req, &v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return &v, resp, nil
+}
+
+func newEndpointRequest(ctx context.Context, c *Client, method, path string, query url.Values, body any, opts ...options.Option[http.Request]) (*http.Request, error) {
+	if len(query) > 0 {
+		u, err := url.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+
+		q := u.Query()
+		for k, vs := range query {
+			for _, v := range vs {
+				q.Add(k, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+
+		path = u.String()
+	}
+
+	return c.NewRequest(ctx, method, path, body, opts...)
+}
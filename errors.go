@@ -0,0 +1,86 @@
+package discogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("resource not found")
+	ErrRateLimited  = errors.New("rate limited")
+
+	// returned before sending anything when an endpoint needs a user
+	// token or oauth and the client only has consumer credentials, or none
+	ErrUserAuthRequired = errors.New("endpoint requires user authentication")
+)
+
+// ErrorResponse is returned by Do, and so every service method, when
+// discogs answers with a status code outside the 2xx range. use errors.Is
+// with the sentinel errors above to branch on the status, or errors.As to
+// get at the message and rate limit snapshot.
+type ErrorResponse struct {
+	StatusCode int
+	Message    string
+	Method     string
+	URL        string
+	Rate       RateLimit
+}
+
+func (e *ErrorResponse) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, msg)
+}
+
+func (e *ErrorResponse) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+
+	return false
+}
+
+func newErrorResponse(resp *Response) *ErrorResponse {
+	e := &ErrorResponse{
+		StatusCode: resp.StatusCode,
+		Rate:       resp.Rate,
+	}
+
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.String()
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return e
+	}
+	resp.Body = io.NopCloser(bytes.NewBuffer(data))
+
+	// discogs sends {"message": "..."} on errors, anything else we just
+	// leave the message empty and fall back to the status text
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		e.Message = body.Message
+	}
+
+	return e
+}
@@ -2,8 +2,8 @@ package discogs
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"net/http"
 )
 
 type IdentityService service
@@ -49,45 +49,61 @@ type Profile struct {
 	CurrAbbr             string  `json:"curr_abbr"`
 }
 
-func (s *IdentityService) Get() (id *Identity, err error) {
-	req, err := s.client.NewRequest("GET", "oauth/identity", nil)
-	if err != nil {
-		err = fmt.Errorf("error building auth check request: %w", err)
-		return
+// Get returns the identity behind the client's credentials. it is only
+// fetched once, later calls are answered from the copy cached on the client.
+// the cache is only for the client's own credentials, calls with others in
+// ctx always fetch.
+func (s *IdentityService) Get(ctx context.Context) (id *Identity, err error) {
+	ctx, end := s.client.startOperation(ctx, "IdentityService.Get")
+	defer end(&err)
+
+	if s.client.defaultCredentials(ctx) {
+		id = s.client.cachedIdentity()
+		if id != nil {
+			return
+		}
 	}
 
-	resp, err := s.client.Do(context.TODO(), req)
+	return s.fetch(ctx)
+}
+
+func (s *IdentityService) fetch(ctx context.Context) (id *Identity, err error) {
+	err = s.client.requireUserAuth(ctx)
 	if err != nil {
-		err = fmt.Errorf("error sending auth check request: %w", err)
 		return
 	}
 
-	if resp.StatusCode != 200 {
-		err = fmt.Errorf("got non-200 from auth check endpoint: %d", resp.StatusCode)
+	req, err := s.client.NewRequest(ctx, http.MethodGet, "oauth/identity", nil)
+	if err != nil {
+		err = fmt.Errorf("error building auth check request: %w", err)
 		return
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&id)
+	_, err = s.client.Do(ctx, req, &id)
 	if err != nil {
-		err = fmt.Errorf("error decoding identity response body: %w", err)
+		err = fmt.Errorf("auth check failed: %w", err)
 		return
 	}
 
+	if s.client.defaultCredentials(ctx) {
+		s.client.setIdentity(id)
+	}
+
 	return
 }
 
-func (s *IdentityService) GetProfile(username string) (profile *Profile, err error) {
+func (s *IdentityService) GetProfile(ctx context.Context, username string) (profile *Profile, err error) {
 	return
 }
 
-func (s *IdentityService) EditProfile(username string) (profile *Profile, err error) {
+func (s *IdentityService) EditProfile(ctx context.Context, username string) (profile *Profile, err error) {
 	return
 }
 
-func (s *IdentityService) GetSubmissions(username string) (err error) {
+func (s *IdentityService) GetSubmissions(ctx context.Context, username string) (err error) {
 	return
 }
 
-func (s *IdentityService) GetContributions(username string, sort string, sortOrder string) (err error) {
+func (s *IdentityService) GetContributions(ctx context.Context, username string, sort string, sortOrder string) (err error) {
 	return
 }
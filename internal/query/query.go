@@ -0,0 +1,148 @@
+// Package query encodes structs into url query parameters, driven by
+// `url:"name,omitempty"` field tags.
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	stringerType = reflect.TypeFor[fmt.Stringer]()
+	timeType     = reflect.TypeFor[time.Time]()
+)
+
+// Values encodes the exported fields of the struct v points to. fields
+// are named by their url tag, or the field name without one, and "-"
+// skips a field. omitempty leaves out zero values and nil pointers are
+// always left out. slices add one value per element, embedded structs
+// are flattened into the parent. a nil v gives empty values.
+func Values(v any) (url.Values, error) {
+	values := url.Values{}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return values, nil
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("query: expected a struct, got %s", rv.Type())
+	}
+
+	err := encodeStruct(values, rv)
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func encodeStruct(values url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		omitempty := opts == "omitempty"
+
+		fv := rv.Field(i)
+
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				err := encodeStruct(values, fv)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if omitempty && fv.IsZero() {
+			continue
+		}
+
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Type().Elem().Kind() != reflect.Uint8 {
+			for j := range fv.Len() {
+				s, err := format(fv.Index(j))
+				if err != nil {
+					return fmt.Errorf("query: field %s: %w", field.Name, err)
+				}
+				values.Add(name, s)
+			}
+			continue
+		}
+
+		s, err := format(fv)
+		if err != nil {
+			return fmt.Errorf("query: field %s: %w", field.Name, err)
+		}
+		values.Add(name, s)
+	}
+
+	return nil
+}
+
+func format(v reflect.Value) (string, error) {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339), nil
+	}
+
+	if v.Type().Implements(stringerType) {
+		return v.Interface().(fmt.Stringer).String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		// only byte slices get here
+		return string(v.Bytes()), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
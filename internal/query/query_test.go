@@ -0,0 +1,145 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+type Page struct {
+	Page    int `url:"page,omitempty"`
+	PerPage int `url:"per_page,omitempty"`
+}
+
+type level int
+
+func (l level) String() string {
+	return [...]string{"low", "high"}[l]
+}
+
+func TestValues(t *testing.T) {
+	year := 1959
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		in   any
+		want string
+	}{
+		{
+			name: "nil",
+			in:   (*Page)(nil),
+			want: "",
+		},
+		{
+			name: "omitempty drops zero values",
+			in: &struct {
+				Q    string `url:"q,omitempty"`
+				Type string `url:"type,omitempty"`
+				N    int    `url:"n"`
+			}{Q: "miles"},
+			want: "n=0&q=miles",
+		},
+		{
+			name: "untagged, skipped and unexported fields",
+			in: struct {
+				Artist string
+				Secret string `url:"-"`
+				hidden string
+			}{Artist: "coltrane", Secret: "x", hidden: "y"},
+			want: "Artist=coltrane",
+		},
+		{
+			name: "embedded structs are flattened",
+			in: &struct {
+				Page
+				Sort string `url:"sort,omitempty"`
+			}{Page: Page{Page: 2, PerPage: 50}, Sort: "year"},
+			want: "page=2&per_page=50&sort=year",
+		},
+		{
+			name: "embedded pointers",
+			in: struct {
+				*Page
+				Sort string `url:"sort"`
+			}{Page: &Page{Page: 3}, Sort: "year"},
+			want: "page=3&sort=year",
+		},
+		{
+			name: "nil embedded pointer",
+			in: struct {
+				*Page
+				Sort string `url:"sort"`
+			}{Sort: "year"},
+			want: "sort=year",
+		},
+		{
+			name: "pointers",
+			in: struct {
+				Year  *int  `url:"year"`
+				Label *int  `url:"label"`
+				Flag  *bool `url:"flag,omitempty"`
+			}{Year: &year},
+			want: "year=1959",
+		},
+		{
+			name: "slices add one value each",
+			in: struct {
+				Genre []string `url:"genre"`
+				IDs   [2]int   `url:"id"`
+				Empty []string `url:"empty,omitempty"`
+			}{Genre: []string{"jazz", "blues"}, IDs: [2]int{1, 2}},
+			want: "genre=jazz&genre=blues&id=1&id=2",
+		},
+		{
+			name: "byte slices are strings",
+			in: struct {
+				Raw []byte `url:"raw"`
+			}{Raw: []byte("abc")},
+			want: "raw=abc",
+		},
+		{
+			name: "time.Time",
+			in: struct {
+				Since time.Time `url:"since"`
+				Until time.Time `url:"until,omitempty"`
+			}{Since: since},
+			want: "since=2024-05-01T12%3A00%3A00Z",
+		},
+		{
+			name: "stringers, bools and floats",
+			in: struct {
+				Level level   `url:"level"`
+				OK    bool    `url:"ok"`
+				Price float64 `url:"price"`
+			}{Level: 1, OK: true, Price: 9.5},
+			want: "level=high&ok=true&price=9.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Values(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := v.Encode(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValuesErrors(t *testing.T) {
+	_, err := Values(42)
+	if err == nil {
+		t.Error("encoding an int didn't fail")
+	}
+
+	_, err = Values(struct {
+		M map[string]string `url:"m"`
+	}{M: map[string]string{}})
+	if err == nil {
+		t.Error("encoding a map field didn't fail")
+	}
+}
@@ -0,0 +1,94 @@
+// Package redact keeps credentials out of anything the client writes down,
+// logs and cassettes alike, so the two can't disagree about what a secret
+// is.
+package redact
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Placeholder replaces every secret value.
+const Placeholder = "REDACTED"
+
+// query parameters discogs accepts credentials in
+var params = []string{"token", "key", "secret", "oauth_token", "oauth_token_secret", "oauth_signature", "oauth_verifier"}
+
+// headers that carry credentials one way or the other
+var headers = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// Query returns a copy of q with the value of every credential parameter
+// replaced by Placeholder.
+func Query(q url.Values) url.Values {
+	out := make(url.Values, len(q))
+	for k, v := range q {
+		out[k] = v
+	}
+
+	for _, p := range params {
+		if out.Has(p) {
+			out.Set(p, Placeholder)
+		}
+	}
+
+	return out
+}
+
+// Form returns body with every credential parameter replaced by
+// Placeholder if it is form encoded, like the oauth token endpoints answer,
+// whatever contentType claims. json and anything else without credential
+// parameters in it comes back untouched.
+func Form(body []byte, contentType string) []byte {
+	if mt, _, _ := mime.ParseMediaType(contentType); mt == "application/json" {
+		return body
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		return body
+	}
+
+	q, err := url.ParseQuery(string(trimmed))
+	if err != nil || !hasSecret(q) {
+		return body
+	}
+
+	return []byte(Query(q).Encode())
+}
+
+func hasSecret(q url.Values) bool {
+	for _, p := range params {
+		if q.Has(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSecretHeader reports whether the header called name carries
+// credentials.
+func IsSecretHeader(name string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// Header returns a copy of h with every credential header replaced by
+// Placeholder.
+func Header(h http.Header) http.Header {
+	h = h.Clone()
+
+	for _, k := range headers {
+		if h.Get(k) != "" {
+			h.Set(k, Placeholder)
+		}
+	}
+
+	return h
+}
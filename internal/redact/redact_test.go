@@ -0,0 +1,128 @@
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	q := url.Values{
+		"token":    {"abc"},
+		"key":      {"k"},
+		"secret":   {"s"},
+		"page":     {"2"},
+		"per_page": {"100"},
+	}
+
+	got := Query(q)
+
+	for _, p := range []string{"token", "key", "secret"} {
+		if got.Get(p) != Placeholder {
+			t.Errorf("%s = %q, want it redacted", p, got.Get(p))
+		}
+	}
+
+	if got.Get("page") != "2" || got.Get("per_page") != "100" {
+		t.Errorf("non secret params changed: %v", got)
+	}
+
+	if q.Get("token") != "abc" {
+		t.Error("Query modified its argument")
+	}
+}
+
+func TestHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Discogs token=abc")
+	h.Set("Cookie", "session=1")
+	h.Set("User-Agent", "test")
+
+	got := Header(h)
+
+	if got.Get("Authorization") != Placeholder || got.Get("Cookie") != Placeholder {
+		t.Errorf("credential headers not redacted: %v", got)
+	}
+
+	if got.Get("Set-Cookie") != "" {
+		t.Error("Header added a header that wasn't there")
+	}
+
+	if got.Get("User-Agent") != "test" {
+		t.Errorf("User-Agent = %q, want test", got.Get("User-Agent"))
+	}
+
+	if h.Get("Authorization") != "Discogs token=abc" {
+		t.Error("Header modified its argument")
+	}
+}
+
+func TestIsSecretHeader(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Authorization": true,
+		"authorization": true,
+		"Set-Cookie":    true,
+		"Content-Type":  false,
+	} {
+		if got := IsSecretHeader(name); got != want {
+			t.Errorf("IsSecretHeader(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestForm(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "token response",
+			body:        "oauth_token=abc&oauth_token_secret=def&oauth_callback_confirmed=true",
+			contentType: "application/x-www-form-urlencoded",
+			want:        "oauth_callback_confirmed=true&oauth_token=REDACTED&oauth_token_secret=REDACTED",
+		},
+		{
+			name:        "token response sent as text",
+			body:        "oauth_token=abc&oauth_token_secret=def",
+			contentType: "text/plain; charset=utf-8",
+			want:        "oauth_token=REDACTED&oauth_token_secret=REDACTED",
+		},
+		{
+			name:        "json",
+			body:        `{"token":"abc"}`,
+			contentType: "application/json",
+			want:        `{"token":"abc"}`,
+		},
+		{
+			name: "json without a content type",
+			body: `{"name":"a=b&token=c"}`,
+			want: `{"name":"a=b&token=c"}`,
+		},
+		{
+			name:        "form without credentials",
+			body:        "b=2&a=1",
+			contentType: "application/x-www-form-urlencoded",
+			want:        "b=2&a=1",
+		},
+		{
+			name:        "plain text",
+			body:        "Invalid consumer.",
+			contentType: "text/plain",
+			want:        "Invalid consumer.",
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(Form([]byte(tt.body), tt.contentType))
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
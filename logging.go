@@ -0,0 +1,136 @@
+package discogs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+	"github.com/dkaman/discogs-golang/internal/redact"
+)
+
+// WithLogger logs every request at debug, failed ones and retries at warn
+// and transport errors at error. credentials never make it into the log,
+// the Authorization header and any token in the query string are redacted.
+func WithLogger(logger *slog.Logger) options.Option[Client] {
+	return func(c *Client) error {
+		if logger == nil {
+			logger = slog.New(discardHandler{})
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+func (c *Client) logRequest(ctx context.Context, req *http.Request, resp *Response, elapsed time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", redactURL(req.URL)),
+		slog.Duration("duration", elapsed),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", redactError(err)))
+		c.logger.LogAttrs(ctx, slog.LevelError, "discogs request failed", attrs...)
+		return
+	}
+
+	attrs = append(attrs,
+		slog.Int("status", resp.StatusCode),
+		slog.Int("ratelimit_remaining", resp.Rate.Remaining),
+	)
+
+	if resp.Paginator.Page > 0 {
+		attrs = append(attrs, slog.Int("page", resp.Paginator.Page), slog.Int("pages", resp.Paginator.Pages))
+	}
+
+	level := slog.LevelDebug
+	if resp.StatusCode >= http.StatusBadRequest {
+		level = slog.LevelWarn
+	}
+
+	if c.logger.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs, slog.Any("headers", redactedHeader(req.Header)))
+	}
+
+	c.logger.LogAttrs(ctx, level, "discogs request", attrs...)
+}
+
+func (c *Client) logRetry(ctx context.Context, req *http.Request, resp *http.Response, err error, attempt int, delay time.Duration) {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", redactURL(req.URL)),
+		slog.Int("attempt", attempt),
+		slog.Duration("delay", delay),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", redactError(err)))
+	} else {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "retrying discogs request", attrs...)
+}
+
+func (c *Client) logLimiterWait(ctx context.Context, req *http.Request, waited time.Duration) {
+	// anything under a millisecond means the limiter let us straight through
+	if waited < time.Millisecond {
+		return
+	}
+
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "waited for rate limiter",
+		slog.String("method", req.Method),
+		slog.String("path", redactURL(req.URL)),
+		slog.Duration("waited", waited),
+	)
+}
+
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+
+	return u.Path + "?" + redact.Query(u.Query()).Encode()
+}
+
+// transport errors from net/http carry the full url, query string and all
+func redactError(err error) error {
+	var ue *url.Error
+	if !errors.As(err, &ue) {
+		return err
+	}
+
+	u, perr := url.Parse(ue.URL)
+	if perr != nil {
+		return err
+	}
+
+	return &url.Error{Op: ue.Op, URL: redactURL(u), Err: ue.Err}
+}
+
+type redactedHeader http.Header
+
+func (h redactedHeader) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(h))
+	for k, v := range h {
+		if redact.IsSecretHeader(k) {
+			attrs = append(attrs, slog.String(k, redact.Placeholder))
+			continue
+		}
+		attrs = append(attrs, slog.String(k, strings.Join(v, ", ")))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// slog.DiscardHandler only showed up in go 1.24
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
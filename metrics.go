@@ -0,0 +1,71 @@
+package discogs
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// Metrics is called by the client for every http round trip it makes.
+// endpoints are route templates like users/{username}/collection/folders/{id}
+// rather than raw paths, so they are safe to use as labels. status is 0
+// when the request never got a response.
+type Metrics interface {
+	ObserveRequest(endpoint string, method string, status int, duration time.Duration)
+	ObserveRateLimit(rate RateLimit)
+	ObserveLimiterWait(duration time.Duration)
+}
+
+func WithMetrics(m Metrics) options.Option[Client] {
+	return func(c *Client) error {
+		if m == nil {
+			m = noopMetrics{}
+		}
+		c.metrics = m
+		return nil
+	}
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(string, string, int, time.Duration) {}
+func (noopMetrics) ObserveRateLimit(RateLimit)                        {}
+func (noopMetrics) ObserveLimiterWait(time.Duration)                  {}
+
+// routeTemplate turns a request path back into the route it was built
+// from. the segment after users is always a username and anything that
+// looks like an id (release ids, or order ids like 1234-5) becomes {id}.
+func routeTemplate(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, seg := range segments {
+		switch {
+		case i > 0 && segments[i-1] == "users":
+			segments[i] = "{username}"
+		case isID(seg):
+			segments[i] = "{id}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func isID(seg string) bool {
+	if seg == "" {
+		return false
+	}
+
+	digits := 0
+	for i, r := range seg {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '-' && i > 0 && i < len(seg)-1:
+		default:
+			return false
+		}
+	}
+
+	return digits > 0
+}
@@ -0,0 +1,229 @@
+package discogs
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// buckets in seconds, stretched out to a minute since limiter waits can
+// take up to a full rate limit window
+var metricsBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// ExpvarMetrics keeps request counters, latency histograms and the last
+// seen rate limit in memory. it is published through expvar and doubles as
+// an http.Handler serving the prometheus text exposition format.
+type ExpvarMetrics struct {
+	mu          sync.Mutex
+	requests    map[requestKey]uint64
+	durations   map[routeKey]*histogram
+	limiterWait histogram
+	rate        RateLimit
+}
+
+type routeKey struct {
+	endpoint string
+	method   string
+}
+
+type requestKey struct {
+	routeKey
+	status int
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewExpvarMetrics publishes the metrics under name in expvar, or not at
+// all if name is empty.
+func NewExpvarMetrics(name string) (*ExpvarMetrics, error) {
+	m := &ExpvarMetrics{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[routeKey]*histogram),
+	}
+
+	if name != "" {
+		if expvar.Get(name) != nil {
+			return nil, fmt.Errorf("expvar %q is already published", name)
+		}
+		expvar.Publish(name, expvar.Func(m.snapshot))
+	}
+
+	return m, nil
+}
+
+func (m *ExpvarMetrics) ObserveRequest(endpoint string, method string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rk := routeKey{endpoint: endpoint, method: method}
+	m.requests[requestKey{routeKey: rk, status: status}]++
+
+	h, ok := m.durations[rk]
+	if !ok {
+		h = &histogram{}
+		m.durations[rk] = h
+	}
+	h.observe(duration)
+}
+
+func (m *ExpvarMetrics) ObserveRateLimit(rate RateLimit) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rate = rate
+}
+
+func (m *ExpvarMetrics) ObserveLimiterWait(duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limiterWait.observe(duration)
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(metricsBuckets))
+	}
+
+	secs := d.Seconds()
+	for i, le := range metricsBuckets {
+		if secs <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += secs
+}
+
+func (m *ExpvarMetrics) snapshot() any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests := make(map[string]uint64, len(m.requests))
+	for k, n := range m.requests {
+		requests[fmt.Sprintf("%s %s %d", k.method, k.endpoint, k.status)] = n
+	}
+
+	durations := make(map[string]map[string]any, len(m.durations))
+	for k, h := range m.durations {
+		durations[k.method+" "+k.endpoint] = h.snapshot()
+	}
+
+	return map[string]any{
+		"requests":          requests,
+		"request_durations": durations,
+		"limiter_wait":      m.limiterWait.snapshot(),
+		"ratelimit":         m.rate,
+	}
+}
+
+func (h *histogram) snapshot() map[string]any {
+	return map[string]any{
+		"count": h.count,
+		"sum":   h.sum,
+	}
+}
+
+func (m *ExpvarMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes every metric in the prometheus text exposition format.
+func (m *ExpvarMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	b.WriteString("# HELP discogs_requests_total Requests sent to the discogs api.\n")
+	b.WriteString("# TYPE discogs_requests_total counter\n")
+
+	reqKeys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		if reqKeys[i].routeKey != reqKeys[j].routeKey {
+			return reqKeys[i].routeKey.less(reqKeys[j].routeKey)
+		}
+		return reqKeys[i].status < reqKeys[j].status
+	})
+	for _, k := range reqKeys {
+		fmt.Fprintf(&b, "discogs_requests_total{%s,status=\"%d\"} %d\n", k.labels(), k.status, m.requests[k])
+	}
+
+	b.WriteString("# HELP discogs_request_duration_seconds Latency of requests to the discogs api.\n")
+	b.WriteString("# TYPE discogs_request_duration_seconds histogram\n")
+
+	routeKeys := make([]routeKey, 0, len(m.durations))
+	for k := range m.durations {
+		routeKeys = append(routeKeys, k)
+	}
+	sort.Slice(routeKeys, func(i, j int) bool { return routeKeys[i].less(routeKeys[j]) })
+	for _, k := range routeKeys {
+		m.durations[k].write(&b, "discogs_request_duration_seconds", k.labels()+",")
+	}
+
+	b.WriteString("# HELP discogs_limiter_wait_seconds Time spent waiting on the rate limiter.\n")
+	b.WriteString("# TYPE discogs_limiter_wait_seconds histogram\n")
+	m.limiterWait.write(&b, "discogs_limiter_wait_seconds", "")
+
+	for _, g := range []struct {
+		name  string
+		help  string
+		value int
+	}{
+		{"discogs_ratelimit_limit", "Requests allowed per rate limit window.", m.rate.Limit},
+		{"discogs_ratelimit_used", "Requests used in the current rate limit window.", m.rate.Used},
+		{"discogs_ratelimit_remaining", "Requests left in the current rate limit window.", m.rate.Remaining},
+	} {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", g.name, g.help, g.name, g.name, g.value)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (h *histogram) write(b *strings.Builder, name string, labels string) {
+	for i, le := range metricsBuckets {
+		var n uint64
+		if h.counts != nil {
+			n = h.counts[i]
+		}
+		fmt.Fprintf(b, "%s_bucket{%sle=\"%s\"} %d\n", name, labels, strconv.FormatFloat(le, 'g', -1, 64), n)
+	}
+	fmt.Fprintf(b, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, h.count)
+
+	labels = strings.TrimSuffix(labels, ",")
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(b, "%s_sum%s %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count%s %d\n", name, labels, h.count)
+}
+
+func (k routeKey) less(o routeKey) bool {
+	if k.endpoint != o.endpoint {
+		return k.endpoint < o.endpoint
+	}
+	return k.method < o.method
+}
+
+func (k routeKey) labels() string {
+	return fmt.Sprintf("endpoint=\"%s\",method=\"%s\"", escapeLabel(k.endpoint), escapeLabel(k.method))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
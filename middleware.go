@@ -0,0 +1,27 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// Handler sends a request built by NewRequest and wraps the reply.
+type Handler func(ctx context.Context, req *http.Request) (*Response, error)
+
+// Middleware wraps the handler Do calls for every request, including the
+// page fetches Pager.Next makes. it can change the request before calling
+// next, look at the Response after, or skip next entirely and hand back a
+// canned response built with NewResponse. requests it short circuits never
+// touch the rate limiter.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware registers middleware in order, the first one given is the
+// outermost and sees the request first and the response last.
+func WithMiddleware(mw ...Middleware) options.Option[Client] {
+	return func(c *Client) error {
+		c.middleware = append(c.middleware, mw...)
+		return nil
+	}
+}
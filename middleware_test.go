@@ -0,0 +1,89 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestMiddlewareShortCircuitWithoutBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("short circuited request reached the server: %s %s", r.Method, r.URL)
+	}))
+	defer srv.Close()
+
+	var seen []string
+	c, err := New(
+		WithToken("secret"),
+		WithBaseURL(srv.URL),
+		WithMiddleware(func(next Handler) Handler {
+			return func(ctx context.Context, req *http.Request) (*Response, error) {
+				seen = append(seen, req.Method+" "+req.URL.Path)
+				return NewResponse(&http.Response{
+					StatusCode: http.StatusNoContent,
+					Header:     http.Header{},
+					Request:    req,
+				}), nil
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Collection.DeleteFolder(context.Background(), "bob", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 1 || seen[0] != "DELETE /users/bob/collection/folders/3" {
+		t.Errorf("middleware saw %v", seen)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Trace", r.Header.Get("X-Trace"))
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *http.Request) (*Response, error) {
+				order = append(order, name+" in")
+				req.Header.Set("X-Trace", req.Header.Get("X-Trace")+name)
+				resp, err := next(ctx, req)
+				order = append(order, name+" out")
+				return resp, err
+			}
+		}
+	}
+
+	c, err := New(WithBaseURL(srv.URL), WithMiddleware(trace("a"), trace("b")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := c.NewRequest(context.Background(), http.MethodGet, "releases/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Do(context.Background(), req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a in", "b in", "b out", "a out"}
+	if !slices.Equal(order, want) {
+		t.Errorf("order %v, want %v", order, want)
+	}
+
+	if got := resp.Header.Get("X-Trace"); got != "ab" {
+		t.Errorf("server saw X-Trace %q, want ab", got)
+	}
+}
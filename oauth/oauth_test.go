@@ -0,0 +1,178 @@
+package oauth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the worked example from appendix A of the oauth core 1.0 spec, which
+// rfc 5849 carried over
+const (
+	exampleConsumerKey    = "dpf43f3p2l4k3l03"
+	exampleConsumerSecret = "kd94hf93k423kf44"
+	exampleNonce          = "kllo9940pd9333jh"
+	exampleTimestamp      = 1191242096
+)
+
+var exampleToken = &Token{Token: "nnch734d00sl2jdk", Secret: "pfkkdhi9sl3r4s00"}
+
+func TestBaseString(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://photos.example.net/photos?file=vacation.jpg&size=original", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := map[string]string{
+		"oauth_consumer_key":     exampleConsumerKey,
+		"oauth_token":            exampleToken.Token,
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        "1191242096",
+		"oauth_nonce":            exampleNonce,
+		"oauth_version":          "1.0",
+	}
+
+	want := "GET&http%3A%2F%2Fphotos.example.net%2Fphotos&file%3Dvacation.jpg%26oauth_consumer_key%3Ddpf43f3p2l4k3l03%26oauth_nonce%3Dkllo9940pd9333jh%26oauth_signature_method%3DHMAC-SHA1%26oauth_timestamp%3D1191242096%26oauth_token%3Dnnch734d00sl2jdk%26oauth_version%3D1.0%26size%3Doriginal"
+
+	if got := baseString(req, params); got != want {
+		t.Errorf("baseString =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSignHMACSHA1(t *testing.T) {
+	config := &Config{
+		ConsumerKey:     exampleConsumerKey,
+		ConsumerSecret:  exampleConsumerSecret,
+		SignatureMethod: HMACSHA1,
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://photos.example.net/photos?file=vacation.jpg&size=original", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = config.signWith(req, exampleToken, nil, exampleNonce, time.Unix(exampleTimestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := authParams(t, req)
+
+	if got, want := params["oauth_signature"], "tR3%2BTy81lMeYAr%2FFid0kMTYa%2FWM%3D"; got != want {
+		t.Errorf("oauth_signature = %s, want %s", got, want)
+	}
+
+	for k, want := range map[string]string{
+		"oauth_consumer_key":     exampleConsumerKey,
+		"oauth_token":            exampleToken.Token,
+		"oauth_nonce":            exampleNonce,
+		"oauth_timestamp":        "1191242096",
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_version":          "1.0",
+	} {
+		if params[k] != want {
+			t.Errorf("%s = %q, want %q", k, params[k], want)
+		}
+	}
+}
+
+func TestSignPlainText(t *testing.T) {
+	config := &Config{ConsumerKey: exampleConsumerKey, ConsumerSecret: exampleConsumerSecret}
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.discogs.com/oauth/identity", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = config.Sign(req, exampleToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := authParams(t, req)
+
+	if got, want := params["oauth_signature"], "kd94hf93k423kf44%26pfkkdhi9sl3r4s00"; got != want {
+		t.Errorf("oauth_signature = %s, want %s", got, want)
+	}
+
+	if got := params["oauth_signature_method"]; got != "PLAINTEXT" {
+		t.Errorf("oauth_signature_method = %s, want PLAINTEXT", got)
+	}
+}
+
+func TestSignFreshNonce(t *testing.T) {
+	config := &Config{ConsumerKey: exampleConsumerKey, ConsumerSecret: exampleConsumerSecret, SignatureMethod: HMACSHA1}
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.discogs.com/oauth/identity", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonces := map[string]bool{}
+	for range 3 {
+		err = config.Sign(req, exampleToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nonce := authParams(t, req)["oauth_nonce"]
+		if nonces[nonce] {
+			t.Fatalf("nonce %s used twice", nonce)
+		}
+		nonces[nonce] = true
+	}
+}
+
+func TestBaseStringSortsRepeatedParams(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://EXAMPLE.com/request?b5=%3D%253D&a3=a&c%40=&a2=r%20b&c2=&a3=2+q", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := baseString(req, map[string]string{"oauth_nonce": "7d8f3e4a"})
+	want := "POST&https%3A%2F%2Fexample.com%2Frequest&a2%3Dr%2520b%26a3%3D2%2520q%26a3%3Da%26b5%3D%253D%25253D%26c%2540%3D%26c2%3D%26oauth_nonce%3D7d8f3e4a"
+
+	if got != want {
+		t.Errorf("baseString =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"abcABC123-._~", "abcABC123-._~"},
+		{"a b", "a%20b"},
+		{"a+b", "a%2Bb"},
+		{"=&%", "%3D%26%25"},
+		{"/?:@", "%2F%3F%3A%40"},
+		{"é", "%C3%A9"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// authParams splits the oauth Authorization header of req into its
+// parameters, still percent encoded.
+func authParams(t *testing.T, req *http.Request) map[string]string {
+	t.Helper()
+
+	header, ok := strings.CutPrefix(req.Header.Get("Authorization"), "OAuth ")
+	if !ok {
+		t.Fatalf("Authorization header %q is not oauth", req.Header.Get("Authorization"))
+	}
+
+	params := map[string]string{}
+	for _, pair := range strings.Split(header, ", ") {
+		k, v, _ := strings.Cut(pair, "=")
+		params[k] = strings.Trim(v, `"`)
+	}
+
+	return params
+}
@@ -0,0 +1,52 @@
+package discogs
+
+import (
+	"context"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// WithTimeout bounds every service method call, pages and retries
+// included, unless the caller's context has an earlier deadline.
+func WithTimeout(d time.Duration) options.Option[Client] {
+	return func(c *Client) error {
+		c.timeout = d
+		return nil
+	}
+}
+
+// WithOperationTimeout overrides the client timeout for one service
+// method, named like its span, e.g. "CollectionService.GetReleasesByFolder".
+func WithOperationTimeout(operation string, d time.Duration) options.Option[Client] {
+	return func(c *Client) error {
+		if c.operationTimeouts == nil {
+			c.operationTimeouts = make(map[string]time.Duration)
+		}
+		c.operationTimeouts[operation] = d
+		return nil
+	}
+}
+
+// startOperation sets up a service method call, applying its timeout and
+// starting its span. call the returned func with a pointer to the method's
+// named error on the way out.
+func (c *Client) startOperation(ctx context.Context, name string, attrs ...Attribute) (context.Context, func(*error)) {
+	cancel := context.CancelFunc(func() {})
+
+	timeout, ok := c.operationTimeouts[name]
+	if !ok {
+		timeout = c.timeout
+	}
+
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	ctx, span := c.tracer.Start(ctx, name, attrs...)
+
+	return ctx, func(err *error) {
+		span.End(*err)
+		cancel()
+	}
+}
@@ -1,28 +1,53 @@
 package discogs
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
-	"io"
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dkaman/discogs-golang/internal/query"
 )
 
+// discogs caps per_page at 100, asking for that by default keeps the
+// number of calls, and so the rate budget spent, down
+const defaultPerPage = 100
+
 var (
 	ErrPageDone  = errors.New("no more pages to iterate")
 	ErrNilClient = errors.New("provided a nil client to init pager")
 )
 
-func readBody[T any](resp *Response, v *T) error {
-	data, err := io.ReadAll(resp.Body)
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// ListOptions is accepted by every list method. with Page set only that
+// page is fetched, otherwise every page is, PerPage at a time. PerPage
+// defaults to 100. which Sort keys are valid depends on the endpoint.
+type ListOptions struct {
+	Page      int       `url:"page,omitempty"`
+	PerPage   int       `url:"per_page,omitempty"`
+	Sort      string    `url:"sort,omitempty"`
+	SortOrder SortOrder `url:"sort_order,omitempty"`
+}
+
+func (o *ListOptions) values() (url.Values, error) {
+	v, err := query.Values(o)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	resp.Body = io.NopCloser(bytes.NewBuffer(data))
+	if v.Get("per_page") == "" {
+		v.Set("per_page", strconv.Itoa(defaultPerPage))
+	}
 
-	return json.Unmarshal(data, v)
+	return v, nil
 }
 
 // stealing from https://vladimir.varank.in/notes/2022/05/a-real-life-use-case-for-generics-in-go-api-for-client-side-pagination/
@@ -31,24 +56,19 @@ type Pager[T any] struct {
 	client   *Client
 }
 
-func NewPager[T any](r *Response, client *Client) (*T, *Pager[T], error) {
+// NewPager picks up from the first page, which the caller has already
+// decoded out of r through Do.
+func NewPager[T any](r *Response, client *Client) (*Pager[T], error) {
 	if client == nil {
-		return nil, nil, ErrNilClient
+		return nil, ErrNilClient
 	}
 
 	pager := &Pager[T]{
-		client: client,
+		client:   client,
 		pageInfo: r.Paginator,
 	}
 
-
-	var respBody T
-	err := readBody(r, &respBody)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return &respBody, pager, nil
+	return pager, nil
 }
 
 func (p *Pager[T]) Next(ctx context.Context) (*T, error) {
@@ -63,27 +83,79 @@ func (p *Pager[T]) Next(ctx context.Context) (*T, error) {
 		return nil, err
 	}
 
-	req, err := p.client.NewRequest("GET", u.Path+"?"+u.RawQuery, nil)
+	req, err := p.client.NewRequest(ctx, http.MethodGet, strings.TrimPrefix(u.Path, "/")+"?"+u.RawQuery, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.Do(ctx, req)
+	var apiResponse T
+	resp, err := p.client.Do(ctx, req, &apiResponse)
 	if err != nil {
 		return nil, err
 	}
 
-	var apiResponse *T
-	err = readBody(resp, &apiResponse)
+	p.pageInfo = resp.Paginator
+
+	return &apiResponse, nil
+}
+
+// paginate hands fn the first page of path and then each following one,
+// until the last page or until fn returns an error. ErrPageDone from fn
+// stops without an error. with single only the first page is fetched.
+func paginate[T any](ctx context.Context, c *Client, path string, q url.Values, single bool, fn func(page *T) error) error {
+	page, resp, err := doEndpoint[T](ctx, c, http.MethodGet, path, q, nil)
+	if err != nil {
+		return err
+	}
+
+	pager, err := NewPager[T](resp, c)
+	if err != nil {
+		return err
+	}
+
+	for {
+		err = fn(page)
+		if err != nil || single {
+			break
+		}
+
+		page, err = pager.Next(ctx)
+		if err != nil {
+			break
+		}
+	}
+
+	if errors.Is(err, ErrPageDone) {
+		return nil
+	}
+
+	return err
+}
+
+// listAll collects the items of a list endpoint, pulling them out of each
+// page P with items.
+func listAll[P, T any](ctx context.Context, c *Client, path string, opts *ListOptions, items func(*P) []T) ([]T, error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+
+	q, err := opts.values()
 	if err != nil {
 		return nil, err
 	}
 
-	p.pageInfo = resp.Paginator
+	var all []T
+	err = paginate(ctx, c, path, q, opts.Page > 0, func(page *P) error {
+		all = append(all, items(page)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return apiResponse, nil
+	return all, nil
 }
 
-func (*Pager[T]) Prev() ([]T, error) {
+func (*Pager[T]) Prev(ctx context.Context) ([]T, error) {
 	return nil, nil
 }
@@ -0,0 +1,50 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagerKeepsBasePath(t *testing.T) {
+	var got string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Path + "?" + r.URL.RawQuery
+		w.Write([]byte(`{"pagination":{"page":2,"pages":2}}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(
+		WithBaseURL(srv.URL+"/proxy/discogs"),
+		WithRateLimiter(NewRateLimiter(6000)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// discogs links to the next page on its own host, only the path and
+	// query are followed
+	p := &Pager[struct{}]{
+		client: c,
+		pageInfo: pageInfo{URLs: pageURLs{
+			Next: "https://api.discogs.com/users/bob/collection/folders/0/releases?page=2",
+		}},
+	}
+
+	_, err = p.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/proxy/discogs/users/bob/collection/folders/0/releases?page=2"
+	if got != want {
+		t.Errorf("requested %q, want %q", got, want)
+	}
+
+	_, err = p.Next(context.Background())
+	if err != ErrPageDone {
+		t.Errorf("after the last page got %v, want ErrPageDone", err)
+	}
+}
@@ -0,0 +1,108 @@
+package discogs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// discogs counts requests in a 60 second moving window
+const rateLimitWindow = 60 * time.Second
+
+// RateLimiter is waited on once before every request Do sends.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RateObserver can be implemented by a RateLimiter that wants to see the
+// rate limit headers of every response.
+type RateObserver interface {
+	Observe(RateLimit)
+}
+
+// NewRateLimiter returns the limiter clients use by default, starting at
+// perMinute requests and tuning itself from the responses it observes. it
+// is safe to share between clients in the same process.
+func NewRateLimiter(perMinute float64) RateLimiter {
+	return newAdaptiveLimiter(perMinute)
+}
+
+// adaptiveLimiter starts out at a fixed rate and then tunes itself from the
+// x-discogs-ratelimit headers. as long as there is more than a tenth of
+// the budget left it runs at the full limit discogs reports, below that it
+// slows down proportionally so other processes sharing the token don't
+// push us into 429s. requests age out of the window between responses, so
+// the estimate of what is left creeps back up on its own while idle.
+type adaptiveLimiter struct {
+	limiter   *rate.Limiter
+	perMinute float64
+
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	observed  time.Time
+}
+
+func newAdaptiveLimiter(perMinute float64) *adaptiveLimiter {
+	return &adaptiveLimiter{
+		limiter:   rate.NewLimiter(rate.Limit(perMinute/rateLimitWindow.Seconds()), 1),
+		perMinute: perMinute,
+	}
+}
+
+func (l *adaptiveLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	l.tune(time.Now())
+	l.mu.Unlock()
+
+	return l.limiter.Wait(ctx)
+}
+
+func (l *adaptiveLimiter) Observe(info RateLimit) {
+	// no headers, nothing to learn from
+	if info.Limit <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = info.Limit
+	l.remaining = info.Remaining
+	l.observed = time.Now()
+	l.tune(l.observed)
+}
+
+// Budget is the limit discogs last reported, or what the limiter was
+// started at before any response came back.
+func (l *adaptiveLimiter) Budget() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit > 0 {
+		return float64(l.limit)
+	}
+	return l.perMinute
+}
+
+func (l *adaptiveLimiter) tune(now time.Time) {
+	if l.observed.IsZero() {
+		return
+	}
+
+	limit := float64(l.limit)
+	elapsed := now.Sub(l.observed).Seconds() / rateLimitWindow.Seconds()
+	remaining := min(float64(l.remaining)+limit*elapsed, limit)
+
+	ceiling := limit / rateLimitWindow.Seconds()
+	reserve := max(limit/10, 1)
+
+	r := ceiling
+	if remaining < reserve {
+		r = ceiling * max(remaining, 1) / reserve
+	}
+
+	l.limiter.SetLimitAt(now, rate.Limit(r))
+}
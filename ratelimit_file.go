@@ -0,0 +1,149 @@
+package discogs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileLimiter shares one budget between every process on the machine that
+// points at the same file. the file holds the timestamps of the requests
+// made in the current window, one per line, and is only ever touched while
+// holding an exclusive lock on it.
+type fileLimiter struct {
+	path      string
+	perMinute int
+}
+
+// NewFileRateLimiter returns a limiter that coordinates with every other
+// process using the same path, allowing perMinute requests between all of
+// them in any 60 second window. it needs flock, on platforms without it
+// the error wraps errors.ErrUnsupported.
+func NewFileRateLimiter(path string, perMinute int) (RateLimiter, error) {
+	if !fileLockSupported {
+		return nil, fmt.Errorf("file rate limiter: %w", errors.ErrUnsupported)
+	}
+
+	if perMinute <= 0 {
+		return nil, fmt.Errorf("requests per minute must be positive, got %d", perMinute)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening rate limit file: %w", err)
+	}
+	f.Close()
+
+	return &fileLimiter{path: path, perMinute: perMinute}, nil
+}
+
+func (l *fileLimiter) Budget() float64 {
+	return float64(l.perMinute)
+}
+
+func (l *fileLimiter) Wait(ctx context.Context) error {
+	for {
+		wait, err := l.reserve(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if wait <= 0 {
+			return nil
+		}
+
+		err = sleep(ctx, wait)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// reserve records a request at now if the window has room for it,
+// otherwise it returns how long until the oldest request ages out.
+func (l *fileLimiter) reserve(ctx context.Context, now time.Time) (time.Duration, error) {
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("error opening rate limit file: %w", err)
+	}
+	defer f.Close()
+
+	err = lockFile(ctx, f)
+	if err != nil {
+		return 0, fmt.Errorf("error locking rate limit file: %w", err)
+	}
+	defer unlockFile(f)
+
+	var stamps []time.Time
+	cutoff := now.Add(-rateLimitWindow)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		n, err := strconv.ParseInt(strings.TrimSpace(scanner.Text()), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		t := time.Unix(0, n)
+		if t.After(cutoff) {
+			stamps = append(stamps, t)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("error reading rate limit file: %w", err)
+	}
+
+	if len(stamps) >= l.perMinute {
+		return stamps[len(stamps)-l.perMinute].Sub(cutoff), nil
+	}
+
+	stamps = append(stamps, now)
+
+	var b strings.Builder
+	for _, t := range stamps {
+		b.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+		b.WriteByte('\n')
+	}
+
+	err = f.Truncate(0)
+	if err != nil {
+		return 0, fmt.Errorf("error truncating rate limit file: %w", err)
+	}
+
+	_, err = f.WriteAt([]byte(b.String()), 0)
+	if err != nil {
+		return 0, fmt.Errorf("error writing rate limit file: %w", err)
+	}
+
+	return 0, nil
+}
+
+// how often lockFile tries again while another process holds the lock,
+// which it only does for as long as it takes to rewrite the file
+const fileLockRetry = 5 * time.Millisecond
+
+// lockFile takes an exclusive lock on f, giving up when ctx is done rather
+// than blocking on a process that holds on to it.
+func lockFile(ctx context.Context, f *os.File) error {
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+
+		err = sleep(ctx, fileLockRetry)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package discogs
+
+import (
+	"errors"
+	"os"
+)
+
+const fileLockSupported = false
+
+func tryLockFile(f *os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func unlockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
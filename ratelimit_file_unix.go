@@ -0,0 +1,28 @@
+//go:build unix
+
+package discogs
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+const fileLockSupported = true
+
+// tryLockFile takes an exclusive lock on f without blocking, reporting
+// false if another process holds it.
+func tryLockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
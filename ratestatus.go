@@ -0,0 +1,129 @@
+package discogs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// RateStatus is the client's view of a rate budget, as of the last
+// response discogs sent.
+type RateStatus struct {
+	// RateLimit is what the last response reported, zero before the first.
+	RateLimit
+	// Observed is when that response came back.
+	Observed time.Time
+	// ResetAt estimates when the whole budget is available again. discogs
+	// counts requests in a moving window, so that is a window after the
+	// last request, if nothing else is sent in the meantime.
+	ResetAt time.Time
+	// Queued is the number of calls waiting on the rate limiter.
+	Queued int
+}
+
+// rateTracker keeps the last rate limit headers a limiter's requests got.
+type rateTracker struct {
+	mu       sync.Mutex
+	rate     RateLimit
+	observed time.Time
+}
+
+type rateThreshold struct {
+	remaining int
+	fn        func(status RateStatus, below bool)
+}
+
+// WithRateThreshold calls fn whenever the remaining budget reported by
+// discogs drops below remaining, with below set, and again once it is back
+// at or above it. fn runs on the goroutine making the call that saw the
+// change, keep it quick. nothing is reported while no calls go out, a job
+// that paused itself can wait for RateStatus().ResetAt instead.
+func WithRateThreshold(remaining int, fn func(status RateStatus, below bool)) options.Option[Client] {
+	return func(c *Client) error {
+		if fn == nil {
+			return errors.New("nil rate threshold callback")
+		}
+		if remaining <= 0 {
+			return fmt.Errorf("rate threshold must be positive, got %d", remaining)
+		}
+
+		c.rateThresholds = append(c.rateThresholds, rateThreshold{remaining: remaining, fn: fn})
+		return nil
+	}
+}
+
+// RateStatus returns the rate budget of the client's own credentials.
+func (c *Client) RateStatus() RateStatus {
+	return c.scheduler.status()
+}
+
+// RateStatusFor returns the rate budget of creds, which is empty if the
+// client hasn't made calls with them yet, or none in the last ten minutes.
+func (c *Client) RateStatusFor(creds *Credentials) RateStatus {
+	if c.ownCredentials(creds) {
+		return c.RateStatus()
+	}
+
+	c.limiters.mu.Lock()
+	cs, ok := c.limiters.schedulers[creds.key()]
+	c.limiters.mu.Unlock()
+
+	if !ok {
+		return RateStatus{}
+	}
+	return cs.status()
+}
+
+func (s *scheduler) status() RateStatus {
+	s.tracker.mu.Lock()
+	status := RateStatus{
+		RateLimit: s.tracker.rate,
+		Observed:  s.tracker.observed,
+	}
+	s.tracker.mu.Unlock()
+
+	if status.Used > 0 {
+		status.ResetAt = status.Observed.Add(rateLimitWindow)
+	}
+
+	status.Queued = s.Len()
+
+	return status
+}
+
+// observeRate records what a response said about the budget of the
+// limiter the request waited on, calling the thresholds it crossed.
+func (c *Client) observeRate(s *scheduler, info RateLimit) {
+	s.tracker.mu.Lock()
+	prev, seen := s.tracker.rate, !s.tracker.observed.IsZero()
+	s.tracker.rate = info
+	s.tracker.observed = time.Now()
+	s.tracker.mu.Unlock()
+
+	if len(c.rateThresholds) == 0 {
+		return
+	}
+
+	// before anything was reported the budget counts as full
+	if !seen {
+		prev.Remaining = info.Limit
+	}
+
+	var status *RateStatus
+	for _, t := range c.rateThresholds {
+		wasBelow := prev.Remaining < t.remaining
+		isBelow := info.Remaining < t.remaining
+		if wasBelow == isBelow {
+			continue
+		}
+
+		if status == nil {
+			st := s.status()
+			status = &st
+		}
+		t.fn(*status, isBelow)
+	}
+}
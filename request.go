@@ -0,0 +1,42 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+)
+
+// requestConfig holds the per request overrides set through the
+// options.Option[http.Request] hook on NewRequest. it rides along in the
+// request context so Do can pick it up without changing its signature.
+type requestConfig struct {
+	retry *RetryPolicy
+	creds *Credentials
+	auth  authenticator
+}
+
+type requestConfigKey struct{}
+
+func requestConfigFrom(ctx context.Context) requestConfig {
+	cfg, _ := ctx.Value(requestConfigKey{}).(requestConfig)
+	return cfg
+}
+
+func updateRequestConfig(req *http.Request, update func(*requestConfig)) {
+	cfg := requestConfigFrom(req.Context())
+	update(&cfg)
+	*req = *req.WithContext(context.WithValue(req.Context(), requestConfigKey{}, cfg))
+}
+
+// bindContext points req at ctx, carrying over the overrides the request
+// options stored in the context it was built with.
+func bindContext(ctx context.Context, req *http.Request) *http.Request {
+	if ctx == req.Context() {
+		return req
+	}
+
+	if cfg, ok := req.Context().Value(requestConfigKey{}).(requestConfig); ok {
+		ctx = context.WithValue(ctx, requestConfigKey{}, cfg)
+	}
+
+	return req.WithContext(ctx)
+}
@@ -10,13 +10,21 @@ import (
 
 type Response struct {
 	*http.Response
-	Rate      rateLimitInfo
+	Rate      RateLimit
 	Paginator pageInfo
+
+	// FromCache is set when the response was served from the client's
+	// cache rather than discogs, Rate is empty in that case.
+	FromCache bool
+
+	// DryRun is set when the call was only planned, see WithDryRun. the
+	// response is made up and Rate is empty.
+	DryRun bool
 }
 
 type responseOption func(*Response) error
 
-type rateLimitInfo struct {
+type RateLimit struct {
 	Limit     int
 	Used      int
 	Remaining int
@@ -37,7 +45,13 @@ type pageURLs struct {
 	Last  string `json:"last,omitempty"`
 }
 
-func newResponse(resp *http.Response) *Response {
+// NewResponse wraps resp, parsing the rate limit headers and pagination
+// info out of it. a nil Body is replaced with http.NoBody.
+func NewResponse(resp *http.Response) *Response {
+	if resp.Body == nil {
+		resp.Body = http.NoBody
+	}
+
 	response := &Response{Response: resp}
 	response.populateRateLimit()
 	response.populatePageInfo()
@@ -45,9 +59,14 @@ func newResponse(resp *http.Response) *Response {
 }
 
 func (r *Response) populateRateLimit() {
-	r.Rate.Limit, _ = strconv.Atoi(r.Header.Get("x-discogs-ratelimit"))
-	r.Rate.Used, _ = strconv.Atoi(r.Header.Get("x-discogs-ratelimit-used"))
-	r.Rate.Remaining, _ = strconv.Atoi(r.Header.Get("x-discogs-ratelimit-remaining"))
+	r.Rate = parseRateLimit(r.Header)
+}
+
+func parseRateLimit(h http.Header) (info RateLimit) {
+	info.Limit, _ = strconv.Atoi(h.Get("x-discogs-ratelimit"))
+	info.Used, _ = strconv.Atoi(h.Get("x-discogs-ratelimit-used"))
+	info.Remaining, _ = strconv.Atoi(h.Get("x-discogs-ratelimit-remaining"))
+	return
 }
 
 func (r *Response) populatePageInfo() {
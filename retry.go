@@ -0,0 +1,140 @@
+package discogs
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// RetryPolicy controls how Do retries requests that fail with a 429, a 5xx
+// or a transport error. the zero value never retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	MaxAttempts int
+	// BaseDelay is doubled on every attempt and capped at MaxDelay, a
+	// random jitter of up to half the delay is taken off the top. a
+	// Retry-After from discogs replaces the backoff but is capped at
+	// MaxDelay too, so a bogus one can't stall the call for an hour.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// RetryNonIdempotent allows retrying POST requests on 5xx and
+	// transport errors. 429s are always retried since discogs rejected
+	// them before doing anything.
+	RetryNonIdempotent bool
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) options.Option[Client] {
+	return func(c *Client) error {
+		c.retryPolicy = policy
+		return nil
+	}
+}
+
+// WithRequestRetryPolicy overrides the client retry policy for a single
+// request, pass it to NewRequest.
+func WithRequestRetryPolicy(policy RetryPolicy) options.Option[http.Request] {
+	return func(req *http.Request) error {
+		updateRequestConfig(req, func(cfg *requestConfig) {
+			cfg.retry = &policy
+		})
+		return nil
+	}
+}
+
+func (p RetryPolicy) shouldRetry(req *http.Request, resp *http.Response, err error, attempt int) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return p.RetryNonIdempotent || isIdempotent(req.Method)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return p.RetryNonIdempotent || isIdempotent(req.Method)
+	}
+
+	return false
+}
+
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if p.MaxDelay > 0 {
+				d = min(d, p.MaxDelay)
+			}
+			return d
+		}
+	}
+
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	return d - rand.N(d/2+1)
+}
+
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+
+	return 0, false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,184 @@
+package discogs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3}
+	nonIdempotent := RetryPolicy{MaxAttempts: 3, RetryNonIdempotent: true}
+
+	cancelled := fmt.Errorf("error doing request: %w", context.Canceled)
+	refused := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		method  string
+		status  int
+		err     error
+		attempt int
+		want    bool
+	}{
+		{name: "GET 503", policy: policy, method: http.MethodGet, status: 503, want: true},
+		{name: "PUT 500", policy: policy, method: http.MethodPut, status: 500, want: true},
+		{name: "DELETE 502", policy: policy, method: http.MethodDelete, status: 502, want: true},
+		{name: "POST 503", policy: policy, method: http.MethodPost, status: 503, want: false},
+		{name: "POST 503 non idempotent allowed", policy: nonIdempotent, method: http.MethodPost, status: 503, want: true},
+		{name: "GET 501", policy: policy, method: http.MethodGet, status: 501, want: false},
+		{name: "GET 404", policy: policy, method: http.MethodGet, status: 404, want: false},
+		{name: "GET 200", policy: policy, method: http.MethodGet, status: 200, want: false},
+		{name: "GET 429", policy: policy, method: http.MethodGet, status: 429, want: true},
+		{name: "POST 429", policy: policy, method: http.MethodPost, status: 429, want: true},
+		{name: "GET transport error", policy: policy, method: http.MethodGet, err: refused, want: true},
+		{name: "POST transport error", policy: policy, method: http.MethodPost, err: refused, want: false},
+		{name: "POST transport error non idempotent allowed", policy: nonIdempotent, method: http.MethodPost, err: refused, want: true},
+		{name: "cancelled", policy: policy, method: http.MethodGet, err: cancelled, want: false},
+		{name: "deadline", policy: nonIdempotent, method: http.MethodGet, err: context.DeadlineExceeded, want: false},
+		{name: "out of attempts", policy: policy, method: http.MethodGet, status: 429, attempt: 3, want: false},
+		{name: "zero policy", method: http.MethodGet, status: 429, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "https://api.discogs.com/releases/1", nil)
+
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			}
+
+			attempt := tt.attempt
+			if attempt == 0 {
+				attempt = 1
+			}
+
+			if got := tt.policy.shouldRetry(req, resp, tt.err, attempt); got != tt.want {
+				t.Errorf("shouldRetry = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+	date := func(d time.Duration) string {
+		return time.Now().Add(d).UTC().Format(http.TimeFormat)
+	}
+
+	tests := []struct {
+		name       string
+		policy     RetryPolicy
+		attempt    int
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{name: "first attempt", policy: policy, attempt: 1, min: 500 * time.Millisecond, max: time.Second},
+		{name: "doubles", policy: policy, attempt: 3, min: 2 * time.Second, max: 4 * time.Second},
+		{name: "capped", policy: policy, attempt: 10, min: 15 * time.Second, max: 30 * time.Second},
+		{name: "overflow is capped", policy: policy, attempt: 70, min: 15 * time.Second, max: 30 * time.Second},
+		{name: "retry after seconds", policy: policy, attempt: 1, retryAfter: "7", min: 7 * time.Second, max: 7 * time.Second},
+		{name: "retry after zero", policy: policy, attempt: 3, retryAfter: "0", min: 0, max: 0},
+		{name: "retry after date", policy: policy, attempt: 1, retryAfter: date(10 * time.Second), min: 8 * time.Second, max: 10 * time.Second},
+		{name: "retry after date passed", policy: policy, attempt: 1, retryAfter: date(-time.Minute), min: 0, max: 0},
+		{name: "retry after capped at max delay", policy: policy, attempt: 1, retryAfter: "3600", min: 30 * time.Second, max: 30 * time.Second},
+		{name: "retry after date capped", policy: policy, attempt: 1, retryAfter: date(time.Hour), min: 30 * time.Second, max: 30 * time.Second},
+		{name: "retry after without max delay", policy: RetryPolicy{BaseDelay: time.Second}, attempt: 1, retryAfter: "90", min: 90 * time.Second, max: 90 * time.Second},
+		{name: "bad retry after falls back", policy: policy, attempt: 1, retryAfter: "soon", min: 500 * time.Millisecond, max: time.Second},
+		{name: "negative retry after falls back", policy: policy, attempt: 1, retryAfter: "-5", min: 500 * time.Millisecond, max: time.Second},
+		{name: "no delays", attempt: 1, min: 0, max: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			for range 20 {
+				d := tt.policy.backoff(tt.attempt, resp)
+				if d < tt.min || d > tt.max {
+					t.Fatalf("backoff = %s, want between %s and %s", d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSendRewindsBodyBetweenAttempts(t *testing.T) {
+	var bodies []string
+
+	// http.Transport rewinds bodies by itself, a transport that doesn't,
+	// like a cassette, relies on send doing it
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		bodies = append(bodies, string(body))
+
+		status := http.StatusOK
+		if len(bodies) < 3 {
+			status = http.StatusTooManyRequests
+		}
+
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+
+	c, err := New(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRateLimiter(NewRateLimiter(6000)),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	req, err := c.NewRequest(ctx, http.MethodPost, "users/bob/collection/folders", map[string]string{"name": "Jazz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var folder Folder
+	_, err = c.Do(ctx, req, &folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bodies) != 3 {
+		t.Fatalf("transport got %d attempts, want 3", len(bodies))
+	}
+
+	for i, body := range bodies {
+		if strings.TrimSpace(body) != `{"name":"Jazz"}` {
+			t.Errorf("attempt %d sent %q", i+1, body)
+		}
+	}
+
+	if folder.Name != "Jazz" {
+		t.Errorf("decoded %+v", folder)
+	}
+}
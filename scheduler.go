@@ -0,0 +1,327 @@
+package discogs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+// Priority orders calls waiting for the rate limiter, interactive calls go
+// first and bulk ones last. calls without one are PriorityNormal.
+type Priority int
+
+const (
+	PriorityNormal Priority = iota
+	PriorityInteractive
+	PriorityBulk
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityNormal:
+		return "normal"
+	case PriorityBulk:
+		return "bulk"
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// rank is the order calls are served in, lowest first
+func (p Priority) rank() int {
+	switch p {
+	case PriorityInteractive:
+		return 0
+	case PriorityBulk:
+		return 2
+	}
+	return 1
+}
+
+// the default for how long a call can be passed over by higher priority
+// ones before it is served as if it were interactive
+const defaultMaxQueueWait = 10 * time.Second
+
+// RateBudgeter can be implemented by a RateLimiter to report how many
+// requests it lets through per minute, which tag quotas are shares of. the
+// limiters this package makes implement it.
+type RateBudgeter interface {
+	Budget() float64
+}
+
+type priorityKey struct{}
+
+type rateTagKey struct{}
+
+// WithPriority returns a context that queues every call made with it at
+// priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// WithRateTag returns a context that counts every call made with it
+// against tag, see WithTagQuota.
+func WithRateTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, rateTagKey{}, tag)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+func rateTagFrom(ctx context.Context) string {
+	tag, _ := ctx.Value(rateTagKey{}).(string)
+	return tag
+}
+
+// WithTagQuota caps calls tagged with WithRateTag(ctx, tag) at share of the
+// rate budget, 0.4 for 40%, in any 60 second window. calls over their
+// quota wait even if the limiter could let them through. the rate limiter
+// has to implement RateBudgeter.
+func WithTagQuota(tag string, share float64) options.Option[Client] {
+	return func(c *Client) error {
+		if share <= 0 || share > 1 {
+			return fmt.Errorf("quota for tag %q must be in (0, 1], got %v", tag, share)
+		}
+
+		if c.quotas == nil {
+			c.quotas = make(map[string]float64)
+		}
+		c.quotas[tag] = share
+		return nil
+	}
+}
+
+// WithMaxQueueWait sets how long a call can be passed over by higher
+// priority ones before it goes ahead of them, 10 seconds by default.
+func WithMaxQueueWait(d time.Duration) options.Option[Client] {
+	return func(c *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("max queue wait must be positive, got %s", d)
+		}
+		c.maxQueueWait = d
+		return nil
+	}
+}
+
+// scheduler puts a priority queue in front of a RateLimiter. only one call
+// at a time waits on the limiter itself, the one at the front of the
+// queue, so whenever the limiter frees up the most important call waiting
+// is the one let through rather than the one that has been blocked in the
+// limiter longest.
+type scheduler struct {
+	limiter RateLimiter
+	quotas  map[string]float64
+	maxWait time.Duration
+
+	mu      sync.Mutex
+	queue   []*waiter
+	busy    bool
+	grants  map[string][]time.Time
+	retryAt *time.Timer
+
+	tracker rateTracker
+}
+
+type waiter struct {
+	priority Priority
+	tag      string
+	queued   time.Time
+	wake     chan struct{}
+}
+
+func newScheduler(limiter RateLimiter, quotas map[string]float64, maxWait time.Duration) (*scheduler, error) {
+	if len(quotas) > 0 {
+		if _, ok := limiter.(RateBudgeter); !ok {
+			return nil, fmt.Errorf("tag quotas need a rate limiter implementing RateBudgeter, got %T", limiter)
+		}
+	}
+
+	if maxWait <= 0 {
+		maxWait = defaultMaxQueueWait
+	}
+
+	return &scheduler{
+		limiter: limiter,
+		quotas:  quotas,
+		maxWait: maxWait,
+		grants:  make(map[string][]time.Time),
+	}, nil
+}
+
+func (s *scheduler) Wait(ctx context.Context) error {
+	w := &waiter{
+		priority: priorityFrom(ctx),
+		tag:      rateTagFrom(ctx),
+		queued:   time.Now(),
+		wake:     make(chan struct{}, 1),
+	}
+
+	s.mu.Lock()
+	s.queue = append(s.queue, w)
+	s.dispatch()
+	s.mu.Unlock()
+
+	for {
+		select {
+		case <-w.wake:
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.remove(w)
+			s.dispatch()
+			s.mu.Unlock()
+			return ctx.Err()
+		}
+
+		s.mu.Lock()
+		if s.busy {
+			s.mu.Unlock()
+			continue
+		}
+		if s.next(time.Now()) != w {
+			// something aged past us since we were woken, pass it on
+			s.dispatch()
+			s.mu.Unlock()
+			continue
+		}
+		s.remove(w)
+		s.busy = true
+		s.mu.Unlock()
+
+		err := s.limiter.Wait(ctx)
+
+		s.mu.Lock()
+		s.busy = false
+		if err == nil {
+			s.grant(w.tag, time.Now())
+		}
+		s.dispatch()
+		s.mu.Unlock()
+
+		return err
+	}
+}
+
+// Len is the number of calls waiting, the one waiting on the limiter
+// itself included.
+func (s *scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.queue)
+	if s.busy {
+		n++
+	}
+	return n
+}
+
+// dispatch wakes the call that should go next, if the limiter is free.
+// when everything queued is over its quota it checks back once the oldest
+// call of those counted ages out of the window.
+func (s *scheduler) dispatch() {
+	if s.busy || len(s.queue) == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	if w := s.next(now); w != nil {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	if s.retryAt != nil {
+		s.retryAt.Stop()
+	}
+	s.retryAt = time.AfterFunc(s.quotaResetIn(now), func() {
+		s.mu.Lock()
+		s.dispatch()
+		s.mu.Unlock()
+	})
+}
+
+// next picks the waiter to go next among those within quota. calls queued
+// longer than maxWait count as interactive, then it is priority order and
+// first come first served within a priority.
+func (s *scheduler) next(now time.Time) *waiter {
+	var best *waiter
+	bestRank := 0
+
+	for _, w := range s.queue {
+		if !s.withinQuota(w.tag, now) {
+			continue
+		}
+
+		rank := w.priority.rank()
+		if now.Sub(w.queued) >= s.maxWait {
+			rank = PriorityInteractive.rank()
+		}
+
+		if best == nil || rank < bestRank || (rank == bestRank && w.queued.Before(best.queued)) {
+			best, bestRank = w, rank
+		}
+	}
+
+	return best
+}
+
+func (s *scheduler) remove(w *waiter) {
+	for i, q := range s.queue {
+		if q == w {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *scheduler) withinQuota(tag string, now time.Time) bool {
+	share, ok := s.quotas[tag]
+	if !ok {
+		return true
+	}
+
+	budget := s.limiter.(RateBudgeter).Budget()
+	allowed := max(int(share*budget), 1)
+
+	return len(s.recentGrants(tag, now)) < allowed
+}
+
+func (s *scheduler) grant(tag string, now time.Time) {
+	if _, ok := s.quotas[tag]; !ok {
+		return
+	}
+	s.grants[tag] = append(s.recentGrants(tag, now), now)
+}
+
+// recentGrants drops the grants for tag that have left the window.
+func (s *scheduler) recentGrants(tag string, now time.Time) []time.Time {
+	cutoff := now.Add(-rateLimitWindow)
+
+	stamps := s.grants[tag]
+	i := 0
+	for i < len(stamps) && !stamps[i].After(cutoff) {
+		i++
+	}
+	stamps = stamps[i:]
+	s.grants[tag] = stamps
+
+	return stamps
+}
+
+func (s *scheduler) quotaResetIn(now time.Time) time.Duration {
+	wait := rateLimitWindow
+	for _, w := range s.queue {
+		if stamps := s.grants[w.tag]; len(stamps) > 0 {
+			wait = min(wait, stamps[0].Add(rateLimitWindow).Sub(now))
+		}
+	}
+	return max(wait, time.Millisecond)
+}
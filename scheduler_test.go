@@ -0,0 +1,303 @@
+package discogs
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// gateLimiter lets one call through per value sent on gate.
+type gateLimiter struct {
+	gate   chan struct{}
+	budget float64
+}
+
+func newGateLimiter() *gateLimiter {
+	return &gateLimiter{gate: make(chan struct{}), budget: 60}
+}
+
+func (l *gateLimiter) Wait(ctx context.Context) error {
+	select {
+	case <-l.gate:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *gateLimiter) Budget() float64 {
+	return l.budget
+}
+
+func TestSchedulerNext(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	tests := []struct {
+		name   string
+		queue  []*waiter
+		grants map[string][]time.Time
+		want   int
+	}{
+		{
+			name: "interactive before normal before bulk",
+			queue: []*waiter{
+				{priority: PriorityBulk, queued: ago(3 * time.Second)},
+				{priority: PriorityNormal, queued: ago(2 * time.Second)},
+				{priority: PriorityInteractive, queued: ago(time.Second)},
+			},
+			want: 2,
+		},
+		{
+			name: "first come first served within a priority",
+			queue: []*waiter{
+				{priority: PriorityNormal, queued: ago(time.Second)},
+				{priority: PriorityNormal, queued: ago(2 * time.Second)},
+				{priority: PriorityBulk, queued: ago(3 * time.Second)},
+			},
+			want: 1,
+		},
+		{
+			name: "calls past max wait count as interactive",
+			queue: []*waiter{
+				{priority: PriorityInteractive, queued: ago(time.Second)},
+				{priority: PriorityBulk, queued: ago(11 * time.Second)},
+			},
+			want: 1,
+		},
+		{
+			name: "aged calls still queue behind older interactive ones",
+			queue: []*waiter{
+				{priority: PriorityBulk, queued: ago(11 * time.Second)},
+				{priority: PriorityInteractive, queued: ago(12 * time.Second)},
+			},
+			want: 1,
+		},
+		{
+			name: "tags over their quota are skipped",
+			queue: []*waiter{
+				{priority: PriorityInteractive, tag: "sync", queued: ago(2 * time.Second)},
+				{priority: PriorityBulk, queued: ago(time.Second)},
+			},
+			grants: map[string][]time.Time{
+				"sync": {ago(30 * time.Second), ago(20 * time.Second)},
+			},
+			want: 1,
+		},
+		{
+			name: "grants age out of the window",
+			queue: []*waiter{
+				{priority: PriorityInteractive, tag: "sync", queued: ago(2 * time.Second)},
+				{priority: PriorityBulk, queued: ago(time.Second)},
+			},
+			grants: map[string][]time.Time{
+				"sync": {ago(90 * time.Second), ago(20 * time.Second)},
+			},
+			want: 0,
+		},
+		{
+			name: "nothing within quota",
+			queue: []*waiter{
+				{priority: PriorityNormal, tag: "sync", queued: ago(time.Second)},
+			},
+			grants: map[string][]time.Time{
+				"sync": {ago(30 * time.Second), ago(20 * time.Second)},
+			},
+			want: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a budget of 60 a minute and a share of 2/60 allows two calls
+			s, err := newScheduler(newGateLimiter(), map[string]float64{"sync": 2.0 / 60}, 10*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s.queue = tt.queue
+			for tag, stamps := range tt.grants {
+				s.grants[tag] = stamps
+			}
+
+			got := s.next(now)
+
+			want := (*waiter)(nil)
+			if tt.want >= 0 {
+				want = tt.queue[tt.want]
+			}
+
+			if got != want {
+				t.Errorf("next picked %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestSchedulerQuotaNeedsBudget(t *testing.T) {
+	_, err := newScheduler(unlimited{}, map[string]float64{"sync": 0.5}, 0)
+	if err == nil {
+		t.Error("quotas on a limiter without a budget didn't fail")
+	}
+}
+
+type unlimited struct{}
+
+func (unlimited) Wait(context.Context) error { return nil }
+
+// startWaiter queues a call on s and waits until it is in the queue, so the
+// order calls are queued in is the order they were started in.
+func startWaiter(t *testing.T, s *scheduler, ctx context.Context, name string, done chan<- string) {
+	t.Helper()
+
+	n := s.Len()
+	go func() {
+		err := s.Wait(ctx)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		done <- name
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for s.Len() == n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s never queued", name)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// release lets the calls waiting on s through one by one, returning the
+// order they went in.
+func release(t *testing.T, l *gateLimiter, done <-chan string, n int) []string {
+	t.Helper()
+
+	var order []string
+	for range n {
+		select {
+		case l.gate <- struct{}{}:
+		case <-time.After(time.Second):
+			t.Fatalf("nothing waiting on the limiter after %v", order)
+		}
+		order = append(order, <-done)
+	}
+	return order
+}
+
+func TestSchedulerDispatchesByPriority(t *testing.T) {
+	l := newGateLimiter()
+	s, err := newScheduler(l, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	done := make(chan string)
+
+	// the first call takes the limiter, the rest queue up behind it
+	startWaiter(t, s, ctx, "first", done)
+	startWaiter(t, s, WithPriority(ctx, PriorityBulk), "bulk", done)
+	startWaiter(t, s, ctx, "normal", done)
+	startWaiter(t, s, WithPriority(ctx, PriorityInteractive), "interactive", done)
+	startWaiter(t, s, WithPriority(ctx, PriorityBulk), "bulk2", done)
+
+	got := release(t, l, done, 5)
+	want := []string{"first", "interactive", "normal", "bulk", "bulk2"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+
+	if n := s.Len(); n != 0 {
+		t.Errorf("Len = %d after everything was served", n)
+	}
+}
+
+func TestSchedulerPromotesAfterMaxWait(t *testing.T) {
+	l := newGateLimiter()
+	s, err := newScheduler(l, nil, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	done := make(chan string)
+
+	startWaiter(t, s, ctx, "first", done)
+	startWaiter(t, s, WithPriority(ctx, PriorityBulk), "bulk", done)
+
+	time.Sleep(30 * time.Millisecond)
+
+	startWaiter(t, s, WithPriority(ctx, PriorityInteractive), "interactive", done)
+
+	got := release(t, l, done, 3)
+	want := []string{"first", "bulk", "interactive"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+}
+
+func TestSchedulerCancelledWaiterPassesOn(t *testing.T) {
+	l := newGateLimiter()
+	s, err := newScheduler(l, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	done := make(chan string)
+
+	startWaiter(t, s, ctx, "first", done)
+
+	cctx, cancel := context.WithCancel(WithPriority(ctx, PriorityInteractive))
+	errc := make(chan error)
+	go func() { errc <- s.Wait(cctx) }()
+	for s.Len() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	startWaiter(t, s, WithPriority(ctx, PriorityBulk), "bulk", done)
+
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("cancelled waiter returned %v", err)
+	}
+
+	got := release(t, l, done, 2)
+	want := []string{"first", "bulk"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+}
+
+func TestSchedulerGrantsCountAgainstQuota(t *testing.T) {
+	l := newGateLimiter()
+	s, err := newScheduler(l, map[string]float64{"sync": 1.0 / 60}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRateTag(context.Background(), "sync")
+	done := make(chan string)
+
+	startWaiter(t, s, ctx, "sync", done)
+	release(t, l, done, 1)
+
+	s.mu.Lock()
+	over := !s.withinQuota("sync", time.Now())
+	s.mu.Unlock()
+
+	if !over {
+		t.Error("tag still within its quota of one call after making one")
+	}
+
+	// an untagged call isn't held back by the tag's quota
+	startWaiter(t, s, context.Background(), "untagged", done)
+	if got := release(t, l, done, 1); got[0] != "untagged" {
+		t.Errorf("served %v", got)
+	}
+}
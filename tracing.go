@@ -0,0 +1,54 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dkaman/discogs-golang/internal/options"
+)
+
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Tracer is a minimal hook for plugging in whatever tracing library the
+// caller uses. the client starts a span for each service method it runs
+// and one for every http call underneath, so a paginated fetch shows up as
+// the method span with a child per page. parenting is left to the tracer,
+// through the context passed to Start.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	End(err error)
+}
+
+// TracePropagator can be implemented by a Tracer to add its trace headers
+// to each outgoing request.
+type TracePropagator interface {
+	Inject(ctx context.Context, header http.Header)
+}
+
+func WithTracer(t Tracer) options.Option[Client] {
+	return func(c *Client) error {
+		if t == nil {
+			t = noopTracer{}
+		}
+		c.tracer = t
+		return nil
+	}
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) End(error)                  {}